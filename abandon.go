@@ -0,0 +1,52 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAbandoned is the cause of the cancellation of a call nobody waits for anymore,
+// see WithCancelAbandoned.
+var ErrAbandoned = errors.New("singleflight: call abandoned")
+
+// WithCancelAbandoned cancels the context of a call started by DoChan with ErrAbandoned
+// when no caller waits for it anymore: its starter and all joined callers stopped waiting,
+// explicitly with the cancel function of DoChanCancel or because their context is done.
+// The key is forgotten, so new callers start a new execution.
+// Without the option, such orphaned executions keep running to completion.
+func WithCancelAbandoned[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.abandon = true
+	}
+}
+
+// detachOnDone calls cancel when ctx is done before the call c completes.
+func (g *Group[K, V]) detachOnDone(ctx context.Context, c *call[V], cancel func()) {
+	if ctx.Done() == nil {
+		return
+	}
+	stop := context.AfterFunc(ctx, cancel)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c.isDone() {
+		stop()
+		return
+	}
+	c.stops = append(c.stops, stop)
+}
+
+// cancelAbandoned cancels the call c if nobody waits for it anymore and the group
+// cancels abandoned calls.
+// Must be called with g.mu held.
+func (g *Group[K, V]) cancelAbandoned(c *call[V]) {
+	if !g.options().abandon || !c.leaderGone || c.dups > 0 {
+		return
+	}
+
+	c.cancel(ErrAbandoned)
+	if key, ok := g.running[c]; ok && g.m[key] == c {
+		delete(g.m, key)
+	}
+}
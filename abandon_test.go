@@ -0,0 +1,91 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoChanDetachOnDone(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.DoChan(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+	if g.ForgetUnshared("key") {
+		t.Fatal("key with a waiter must not be forgotten")
+	}
+	cancel()
+	for !g.ForgetUnshared("key") {
+		time.Sleep(time.Millisecond) // AfterFunc runs in its own goroutine
+	}
+}
+
+func TestCancelAbandoned(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithCancelAbandoned[string, int]())
+	causes := make(chan error, 1)
+	started := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+		return 0, ctx.Err()
+	}
+
+	_, cancelLeader := g.DoChanCancel(context.Background(), "key", fn)
+	<-started
+	waiterCtx, cancelWaiter := context.WithCancel(context.Background())
+	g.DoChan(waiterCtx, "key", fn)
+
+	cancelLeader()
+	select {
+	case <-causes:
+		t.Fatal("call canceled while a waiter is still interested")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancelWaiter()
+	if cause := <-causes; !errors.Is(cause, ErrAbandoned) {
+		t.Errorf("cause = %v; want %v", cause, ErrAbandoned)
+	}
+}
+
+func TestDoChanCanceledDelivers(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := g.DoChan(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+	cancel()
+	select {
+	case res := <-ch:
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("result = %+v; want context.Canceled", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DoChan with a canceled context never delivered")
+	}
+}
@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// Leader is the handle of the caller responsible for producing the result of a call
+// registered by Acquire. The leader must complete the call exactly once with
+// SetResult or Fail, otherwise all followers wait forever.
+type Leader[K comparable, V any] struct {
+	g    *Group[K, V]
+	c    *call[V]
+	key  K
+	ctx  context.Context
+	once sync.Once
+}
+
+// Follower is the handle of a caller which joined a call in progress.
+type Follower[V any] struct {
+	c *call[V]
+}
+
+// Acquire registers interest in the result for key without providing a function.
+// Exactly one of leader and follower is not nil: the first caller for a key becomes
+// the leader and must complete the call, subsequent callers become followers waiting
+// for that result. Acquire decouples duplicate suppression from closure-style execution,
+// for results that arrive from callbacks or messages.
+// Acquire returns ErrClosed after the group is closed and a CycleError if ctx belongs
+// to a call chain which already contains key.
+func (g *Group[K, V]) Acquire(ctx context.Context, key K) (leader *Leader[K, V], follower *Follower[V], err error) {
+	c, isLeader, callCtx, err := g.register(ctx, key, nil, nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isLeader {
+		return nil, &Follower[V]{c: c}, nil
+	}
+
+	g.hookStart(c, key)
+	return &Leader[K, V]{g: g, c: c, key: key, ctx: callCtx}, nil, nil
+}
+
+// Context returns the leader context, which is canceled when the call is
+// completed or aborted by Drain.
+func (l *Leader[K, V]) Context() context.Context {
+	return l.ctx
+}
+
+// SetResult completes the call with the value v.
+// Only the first call of SetResult or Fail has an effect.
+func (l *Leader[K, V]) SetResult(v V) {
+	l.complete(v, nil)
+}
+
+// Fail completes the call with the error err.
+// Only the first call of SetResult or Fail has an effect.
+func (l *Leader[K, V]) Fail(err error) {
+	var zero V
+	l.complete(zero, err)
+}
+
+func (l *Leader[K, V]) complete(v V, err error) {
+	l.once.Do(func() {
+		l.g.finish(l.c, l.key, v, err)
+	})
+}
+
+// Wait waits for the leader to complete the call and returns its result.
+// If ctx is done first, Wait returns ctx.Err(); the call itself is not affected.
+func (f *Follower[V]) Wait(ctx context.Context) (V, error) {
+	select {
+	case <-f.c.done:
+		return f.c.val, f.c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
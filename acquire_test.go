@@ -0,0 +1,63 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g Group[string, int]
+	leader, follower, err := g.Acquire(ctx, "key")
+	if err != nil || leader == nil || follower != nil {
+		t.Fatalf("first Acquire = %v, %v, %v; want leader", leader, follower, err)
+	}
+
+	leader2, follower, err := g.Acquire(ctx, "key")
+	if err != nil || leader2 != nil || follower == nil {
+		t.Fatalf("second Acquire = %v, %v, %v; want follower", leader2, follower, err)
+	}
+
+	ch := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		t.Error("function must not be called while the key is acquired")
+		return 0, nil
+	})
+
+	leader.SetResult(42)
+	leader.Fail(errors.New("ignored"))
+
+	if v, err := follower.Wait(ctx); v != 42 || err != nil {
+		t.Errorf("Wait = %v, %v; want 42, nil", v, err)
+	}
+	if res := <-ch; res.Val != 42 || res.Err != nil || !res.Shared {
+		t.Errorf("DoChan = %+v; want shared 42", res)
+	}
+
+	if _, ok := <-leader.Context().Done(); ok {
+		t.Error("leader context must be canceled after completion")
+	}
+}
+
+func TestFollowerWaitContext(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	leader, _, _ := g.Acquire(context.Background(), "key")
+	_, follower, _ := g.Acquire(context.Background(), "key")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := follower.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	leader.SetResult(1)
+	if v, err := follower.Wait(context.Background()); v != 1 || err != nil {
+		t.Errorf("Wait = %v, %v; want 1, nil", v, err)
+	}
+}
@@ -0,0 +1,49 @@
+package singleflight
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled is matched by the errors of calls canceled with Cancel.
+var ErrCanceled = errors.New("singleflight: call canceled")
+
+// Cancel breaks the in-flight call for key, e.g. a stuck call, without restarting the process:
+// the context of the leader is canceled and all callers immediately receive an error
+// matching ErrCanceled and wrapping cause, if not nil. The eventual result of the
+// function is discarded. Cancel returns false if no call for key is in flight.
+func (g *Group[K, V]) Cancel(key K, cause error) bool {
+	g.mu.Lock()
+	c, ok := g.m[key]
+	g.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	err := fmt.Errorf("%w for key %v", ErrCanceled, g.keyLabel(key))
+	if cause != nil {
+		err = fmt.Errorf("%w: %w", err, cause)
+	}
+	c.cancel(err)
+
+	var zero V
+	g.finish(c, key, zero, err)
+	return true
+}
+
+// CompleteInFlight completes the in-flight call for key with the result v, err: all callers
+// receive it immediately and the eventual result of the (possibly hung) function is discarded.
+// It is meant for manual remediation and for testing hang scenarios. The context of the
+// leader is canceled as when the function returns. CompleteInFlight returns false
+// if no call for key is in flight.
+func (g *Group[K, V]) CompleteInFlight(key K, v V, err error) bool {
+	g.mu.Lock()
+	c, ok := g.m[key]
+	g.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	g.finish(c, key, v, err)
+	return true
+}
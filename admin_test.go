@@ -0,0 +1,77 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+	stuckErr := errors.New("stuck")
+
+	release := make(chan struct{})
+	defer close(release)
+	leaderCtx := make(chan context.Context, 1)
+	leader := g.DoChan(ctx, "key", func(ctx context.Context) (int, error) {
+		leaderCtx <- ctx
+		<-release // ignores its context
+		return 1, nil
+	})
+	lctx := <-leaderCtx
+	waiter := g.DoChan(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if !g.Cancel("key", stuckErr) {
+		t.Fatal("Cancel must find the in-flight call")
+	}
+	for _, ch := range []<-chan Result[int]{leader, waiter} {
+		if res := <-ch; !errors.Is(res.Err, ErrCanceled) || !errors.Is(res.Err, stuckErr) {
+			t.Errorf("result error = %v; want ErrCanceled wrapping %v", res.Err, stuckErr)
+		}
+	}
+	if cause := context.Cause(lctx); !errors.Is(cause, ErrCanceled) {
+		t.Errorf("leader context cause = %v; want %v", cause, ErrCanceled)
+	}
+	if g.Cancel("key", nil) {
+		t.Error("Cancel of a completed call must return false")
+	}
+}
+
+func TestCompleteInFlight(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	if !g.CompleteInFlight("key", 42, nil) {
+		t.Fatal("CompleteInFlight must find the in-flight call")
+	}
+	if res := <-leader; res.Val != 42 || res.Err != nil {
+		t.Errorf("result = %+v; want 42, nil", res)
+	}
+
+	// the hung function returns later, its result is discarded
+	close(release)
+	if v, _, _ := g.Do(ctx, "key", func(context.Context) (int, error) { return 3, nil }); v != 3 {
+		t.Errorf("Do after completion = %d; want a new execution", v)
+	}
+	if g.CompleteInFlight("missing", 0, nil) {
+		t.Error("CompleteInFlight of a missing key must return false")
+	}
+}
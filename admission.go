@@ -0,0 +1,48 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadlineTooShort is matched by the errors returned when the deadline of a caller
+// expires before the in-flight call it would join is expected to complete,
+// see WithDeadlineAdmission.
+var ErrDeadlineTooShort = errors.New("singleflight: deadline too short")
+
+// WithDeadlineAdmission makes callers fail fast with an error matching ErrDeadlineTooShort
+// instead of joining an in-flight call they can't benefit from: the remaining time of
+// the call, estimated from the previous executions of the key, exceeds the time left
+// before the deadline of their context. It enables latency estimates for size keys,
+// see WithLatencyEstimates. Leaders are not affected.
+func WithDeadlineAdmission[K comparable, V any](size int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.admission = true
+		WithLatencyEstimates[K, V](size)(o)
+	}
+}
+
+// admit returns an error if a caller with ctx joining the in-flight call c for key
+// at now would reach its deadline before the call is expected to complete.
+// Must be called with g.mu held.
+func (g *Group[K, V]) admit(ctx context.Context, c *call[V], key K, now time.Time) error {
+	if !g.options().admission {
+		return nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	estimate, ok := g.estimate(key)
+	if !ok {
+		return nil
+	}
+
+	remaining := estimate - now.Sub(c.started)
+	if left := deadline.Sub(now); left < remaining {
+		return fmt.Errorf("%w: %s left, call expected to complete in %s", ErrDeadlineTooShort, left, remaining)
+	}
+	return nil
+}
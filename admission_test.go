@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlineAdmission(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithDeadlineAdmission[string, int](10))
+	slow := func(release chan struct{}) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) {
+			<-release
+			return 1, nil
+		}
+	}
+
+	// learn that the key takes about 100ms
+	_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		time.Sleep(100 * time.Millisecond)
+		return 1, nil
+	})
+
+	release := make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", slow(release))
+	for g.Stats().InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := g.Do(ctx, "key", slow(release)); !errors.Is(err, ErrDeadlineTooShort) {
+		t.Errorf("Do with a short deadline error = %v; want %v", err, ErrDeadlineTooShort)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	joined := g.DoChan(ctx, "key", slow(release))
+	close(release)
+	if res := <-joined; res.Err != nil || !res.Shared {
+		t.Errorf("caller with a long deadline = %+v; want joined", res)
+	}
+	<-ch
+}
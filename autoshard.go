@@ -0,0 +1,84 @@
+package singleflight
+
+import "time"
+
+// AutoShard configures the tuning of the shard count by NewAutoSharded.
+type AutoShard struct {
+	// Min and Max bound the number of shards; the tuner starts with Min.
+	Min, Max int
+	// Interval is the period between two adjustments, 1s if zero.
+	Interval time.Duration
+	// Grow is the shard contention, in seconds spent waiting for the shard locks per second,
+	// above which the number of shards doubles; 0.01 if zero.
+	Grow float64
+	// Shrink is the shard contention below which the number of shards halves; 0.0001 if zero.
+	Shrink float64
+}
+
+// NewAutoSharded creates a Sharded whose number of shards is adjusted within the bounds
+// of cfg every interval according to the contention of the shards, measured as the time
+// new calls wait for the shard locks, so the right number doesn't need to be guessed up front.
+// Resizing keeps the calls in flight and the per-key state, see Sharded.Resize.
+// The tuner doesn't resize while the shards are paused, and stops on Close.
+func NewAutoSharded[K comparable, V any](cfg AutoShard, opts ...Option[K, V]) *Sharded[K, V] {
+	cfg.Min = max(cfg.Min, 1)
+	cfg.Max = max(cfg.Max, cfg.Min)
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Grow <= 0 {
+		cfg.Grow = 0.01
+	}
+	if cfg.Shrink <= 0 {
+		cfg.Shrink = 0.0001
+	}
+
+	s := NewSharded(cfg.Min, opts...)
+	s.stop = make(chan struct{})
+	go s.autoTune(cfg)
+	return s
+}
+
+// autoTune adjusts the number of shards every interval until the tuner is stopped.
+func (s *Sharded[K, V]) autoTune(cfg AutoShard) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	lastWait, lastTime := s.lockWait(), time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			wait := s.lockWait()
+			contention := time.Duration(wait-lastWait).Seconds() / now.Sub(lastTime).Seconds()
+			lastWait, lastTime = wait, now
+			if s.isPaused() {
+				continue
+			}
+			if n := tunedShards(cfg, s.Len(), contention); n != s.Len() {
+				s.Resize(n)
+			}
+		}
+	}
+}
+
+// isPaused reports whether the shards are paused by Sharded.Pause.
+func (s *Sharded[K, V]) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+// tunedShards returns the number of shards for the given contention, starting from n.
+func tunedShards(cfg AutoShard, n int, contention float64) int {
+	switch {
+	case contention > cfg.Grow:
+		return min(n*2, cfg.Max)
+	case contention < cfg.Shrink:
+		return max(n/2, cfg.Min)
+	default:
+		return n
+	}
+}
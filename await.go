@@ -0,0 +1,63 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Await waits for the result on ch, as returned by DoChan, or for ctx to be done.
+// It returns the result like Do, or the context error if ctx is done first.
+func Await[V any](ctx context.Context, ch <-chan Result[V]) (v V, shared bool, err error) { // nolint: revive
+	select {
+	case res := <-ch:
+		return res.Val, res.Shared, res.Err
+	case <-ctx.Done():
+		return v, false, ctx.Err()
+	}
+}
+
+// WaitAll waits for the results on all channels, or for ctx to be done.
+// The results are in the order of the channels; err joins the errors of the results,
+// or is the context error if ctx is done first.
+func WaitAll[V any](ctx context.Context, chs ...<-chan Result[V]) (results []Result[V], err error) {
+	results = make([]Result[V], len(chs))
+	var errs []error
+	for i, ch := range chs {
+		select {
+		case results[i] = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if results[i].Err != nil {
+			errs = append(errs, results[i].Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// WaitAny waits for the first successful result on the channels, or for ctx to be done.
+// It returns the index of the channel and its result. If all results fail,
+// it returns index -1 and the joined errors; if ctx is done first, the context error.
+func WaitAny[V any](ctx context.Context, chs ...<-chan Result[V]) (index int, res Result[V], err error) {
+	cases := make([]reflect.SelectCase, 0, len(chs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	for _, ch := range chs {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+	}
+
+	var errs []error
+	for remaining := len(chs); remaining > 0; remaining-- {
+		chosen, recv, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return -1, res, ctx.Err()
+		}
+		r := recv.Interface().(Result[V]) // nolint: forcetypeassert
+		if r.Err == nil {
+			return chosen - 1, r, nil
+		}
+		errs = append(errs, r.Err)
+		cases[chosen].Chan = reflect.Value{} // a zero channel is never selected
+	}
+	return -1, res, errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAwait(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	if v, _, err := Await(context.Background(), ch); v != 1 || err != nil {
+		t.Errorf("Await = %v, %v; want 1, nil", v, err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	ch = g.DoChan(context.Background(), "slow", func(context.Context) (int, error) {
+		<-release
+		return 2, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := Await(ctx, ch); !errors.Is(err, context.Canceled) {
+		t.Errorf("Await error = %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+	someErr := errors.New("some error")
+
+	results, err := WaitAll(ctx,
+		g.DoChan(ctx, "a", func(context.Context) (int, error) { return 1, nil }),
+		g.DoChan(ctx, "b", func(context.Context) (int, error) { return 0, someErr }),
+		g.DoChan(ctx, "c", func(context.Context) (int, error) { return 3, nil }),
+	)
+	if !errors.Is(err, someErr) {
+		t.Errorf("WaitAll error = %v; want %v", err, someErr)
+	}
+	if len(results) != 3 || results[0].Val != 1 || results[2].Val != 3 {
+		t.Errorf("WaitAll results = %+v", results)
+	}
+}
+
+func TestWaitAny(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+	someErr := errors.New("some error")
+	release := make(chan struct{})
+	defer close(release)
+
+	i, res, err := WaitAny(ctx,
+		g.DoChan(ctx, "slow", func(context.Context) (int, error) {
+			<-release
+			return 1, nil
+		}),
+		g.DoChan(ctx, "bad", func(context.Context) (int, error) { return 0, someErr }),
+		g.DoChan(ctx, "good", func(context.Context) (int, error) { return 3, nil }),
+	)
+	if i != 2 || res.Val != 3 || err != nil {
+		t.Errorf("WaitAny = %d, %+v, %v; want 2, 3, nil", i, res, err)
+	}
+
+	i, _, err = WaitAny(ctx,
+		g.DoChan(ctx, "bad1", func(context.Context) (int, error) { return 0, someErr }),
+		g.DoChan(ctx, "bad2", func(context.Context) (int, error) { return 0, someErr }),
+	)
+	if i != -1 || !errors.Is(err, someErr) {
+		t.Errorf("WaitAny of failures = %d, %v; want -1, %v", i, err, someErr)
+	}
+}
@@ -0,0 +1,133 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorBudget describes the error budget of a group, see WithErrorBudget.
+type ErrorBudget struct {
+	// Objective is the target success rate, e.g. 0.999.
+	Objective float64
+	// Executions and Failures are the number of executions in the window and of those which failed.
+	Executions int
+	Failures   int
+	// SuccessRate is the share of the executions in the window which succeeded, 1 without executions.
+	SuccessRate float64
+	// Remaining is the share of the budget left: 1 without failures, 0 or less once exhausted.
+	Remaining float64
+}
+
+// Exhausted reports whether the failures used up the budget.
+func (b ErrorBudget) Exhausted() bool {
+	return b.Remaining <= 0
+}
+
+// budgetWindow holds the outcomes of the last executions for WithErrorBudget.
+type budgetWindow struct {
+	failed    []bool // ring buffer of outcomes; lazily initialized
+	next      int
+	n         int
+	failures  int
+	exhausted bool // fn was called and the budget was not replenished since
+}
+
+// WithErrorBudget tracks the success rate of the last window executions against objective,
+// e.g. 0.999, so the window allows (1-objective)*window failures: the error budget.
+// fn is called when the budget becomes exhausted and again only after it was replenished,
+// so the application can react, e.g. open a breaker or serve stale values.
+// Executions failing because the context of their caller is done are not counted,
+// but executions exceeding the timeout of the group are, see WithTimeout. See ErrorBudget.
+func WithErrorBudget[K comparable, V any](objective float64, window int, fn func(ErrorBudget)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.objective = objective
+		o.budgetWindow = window
+		o.onBudgetExhausted = fn
+	}
+}
+
+// ErrorBudget returns the current error budget of the group.
+// It is zero without WithErrorBudget.
+func (g *Group[K, V]) ErrorBudget() ErrorBudget {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.options().budgetWindow <= 0 {
+		return ErrorBudget{}
+	}
+	return g.budget.stats(g.options().objective, g.options().budgetWindow)
+}
+
+// stats returns the error budget of the window for objective and size.
+func (w *budgetWindow) stats(objective float64, size int) ErrorBudget {
+	b := ErrorBudget{
+		Objective:   objective,
+		Executions:  w.n,
+		Failures:    w.failures,
+		SuccessRate: 1,
+		Remaining:   1,
+	}
+	if w.n > 0 {
+		b.SuccessRate = 1 - float64(w.failures)/float64(w.n)
+	}
+	if allowed := (1 - objective) * float64(size); allowed > 0 {
+		b.Remaining = 1 - float64(w.failures)/allowed
+	} else if w.failures > 0 {
+		b.Remaining = 0
+	}
+	return b
+}
+
+// callerCanceled reports whether the execution of c failed because the context of its
+// caller is done, rather than because of fn or of the timeout of the group.
+// Must be called before the context of c is canceled on completion.
+func (c *call[V]) callerCanceled() bool {
+	if c.ctx == nil || c.ctx.Err() == nil {
+		return false
+	}
+	if !errors.Is(c.err, context.Canceled) && !errors.Is(c.err, context.DeadlineExceeded) {
+		return false
+	}
+	var timeout *TimeoutError
+	return !errors.As(context.Cause(c.ctx), &timeout)
+}
+
+// recordBudget counts an execution which returned err in the error budget, unless it
+// failed because its caller canceled it, and returns the function reporting the exhaustion
+// of the budget, if any, to call once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) recordBudget(err error, callerCanceled bool) func() {
+	size := g.options().budgetWindow
+	if size <= 0 || callerCanceled {
+		return func() {}
+	}
+
+	w := &g.budget
+	if len(w.failed) != size {
+		*w = budgetWindow{failed: make([]bool, size)}
+	}
+	if w.n == size {
+		if w.failed[w.next] {
+			w.failures--
+		}
+	} else {
+		w.n++
+	}
+	w.failed[w.next] = err != nil
+	if err != nil {
+		w.failures++
+	}
+	w.next = (w.next + 1) % size
+
+	b := w.stats(g.options().objective, size)
+	if !b.Exhausted() {
+		w.exhausted = false
+		return func() {}
+	}
+	if w.exhausted || g.options().onBudgetExhausted == nil {
+		return func() {}
+	}
+	w.exhausted = true
+	fn := g.options().onBudgetExhausted
+	return func() { fn(b) }
+}
@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorBudget(t *testing.T) {
+	t.Parallel()
+
+	var exhausted []ErrorBudget
+	// 8 executions at 0.75 allow 2 failures
+	g := NewGroup(WithErrorBudget[string, int](0.75, 8, func(b ErrorBudget) {
+		exhausted = append(exhausted, b)
+	}))
+	ctx := context.Background()
+	someErr := errors.New("some error")
+	run := func(err error) {
+		_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) { return 0, err })
+	}
+
+	if b := g.ErrorBudget(); b.Remaining != 1 || b.SuccessRate != 1 {
+		t.Errorf("initial budget = %+v", b)
+	}
+	for range 6 {
+		run(nil)
+	}
+	run(someErr)
+	if b := g.ErrorBudget(); b.Executions != 7 || b.Failures != 1 || b.Remaining != 0.5 || b.Exhausted() {
+		t.Errorf("budget = %+v; want half of it remaining", b)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, _ = g.Do(canceled, "key", func(ctx context.Context) (int, error) { return 0, ctx.Err() })
+	run(someErr)
+	run(someErr)
+	if len(exhausted) != 1 || exhausted[0].Failures != 2 {
+		t.Fatalf("exhaustion reports = %+v; want one at 2 failures", exhausted)
+	}
+
+	// the failures leave the window
+	for range 8 {
+		run(nil)
+	}
+	if b := g.ErrorBudget(); b.Failures != 0 || b.Exhausted() {
+		t.Errorf("budget = %+v; want replenished", b)
+	}
+	run(someErr)
+	run(someErr)
+	if len(exhausted) != 2 {
+		t.Errorf("exhaustion reports = %d; want another one after replenishment", len(exhausted))
+	}
+}
+
+func TestErrorBudgetTimeout(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(
+		WithErrorBudget[string, int](0.5, 4, nil),
+		WithTimeout[string, int](time.Millisecond),
+	)
+	ctx := context.Background()
+	wait := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, _ = g.Do(canceled, "key", wait)
+	if b := g.ErrorBudget(); b.Executions != 0 || g.Stats().Executions != 1 {
+		t.Errorf("budget = %+v; want the cancellation by the caller not counted", b)
+	}
+	_, _, _ = g.Do(ctx, "key", wait)
+	if b := g.ErrorBudget(); b.Executions != 1 || b.Failures != 1 {
+		t.Errorf("budget = %+v; want the timeout counted as a failure", b)
+	}
+}
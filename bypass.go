@@ -0,0 +1,48 @@
+package singleflight
+
+import "context"
+
+// DoBypass executes fn for key without joining an in-flight call, for callers which
+// must have fresh data (admin refresh, consistency-critical reads).
+// If replace is true, the execution replaces the in-flight call of key: callers arriving
+// while it runs join it instead of the older call, whose callers still receive its result.
+// Otherwise the execution is independent and its result is not shared.
+// Like the executions started by Do, DoBypass waits while the group is paused, returns
+// ErrClosed after the group is closed, a CycleError on cycles, and respects cooldowns,
+// load shedding and quotas. A disabled group (see SetEnabled) never replaces the call.
+func (g *Group[K, V]) DoBypass(ctx context.Context, key K, fn doFunc[V], replace bool) (v V, shared bool, err error) { // nolint: revive
+	if err = checkCycle(ctx, g, key, g.keyLabel(key)); err != nil {
+		return v, false, err
+	}
+	tenant := g.tenantFor(ctx, key)
+	load := g.checkLoad()
+
+	g.mu.Lock()
+	if err = g.admitCaller(ctx); err != nil {
+		g.mu.Unlock()
+		return v, false, err
+	}
+	if err = g.admitLeader(key, tenant, load); err != nil {
+		g.mu.Unlock()
+		return v, false, err
+	}
+	g.stats.calls.Add(1)
+	c, callCtx := g.newCall(ctx, key)
+	c.tenant = tenant
+	if replace && !g.disabled.Load() {
+		if g.m == nil {
+			g.m = make(map[K]*call[V])
+		}
+		g.m[key] = c
+	}
+	notify := g.crossWatermarks()
+	alert := g.countDedup(false)
+	keys := g.countKey(key)
+	g.mu.Unlock()
+
+	notify()
+	alert()
+	keys()
+	g.doCall(callCtx, c, key, fn)
+	return c.val, c.dups > 0, c.err
+}
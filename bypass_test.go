@@ -0,0 +1,109 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoBypass(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	oldDone := make(chan int)
+	go func() {
+		v, _, _ := g.Do(ctx, "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		oldDone <- v
+	}()
+	<-started
+
+	// independent execution
+	v, shared, err := g.DoBypass(ctx, "key", func(context.Context) (int, error) { return 2, nil }, false)
+	if v != 2 || shared || err != nil {
+		t.Errorf("DoBypass = %v, %v, %v; want 2, false, nil", v, shared, err)
+	}
+
+	// replacing execution: later callers join it
+	replStarted := make(chan struct{})
+	replRelease := make(chan struct{})
+	replDone := make(chan int)
+	go func() {
+		v, _, _ := g.DoBypass(ctx, "key", func(context.Context) (int, error) {
+			close(replStarted)
+			<-replRelease
+			return 3, nil
+		}, true)
+		replDone <- v
+	}()
+	<-replStarted
+
+	joined := make(chan int)
+	go func() {
+		v, _, _ := g.Do(ctx, "key", func(context.Context) (int, error) { return 4, nil })
+		joined <- v
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	if v := <-oldDone; v != 1 {
+		t.Errorf("old call = %d; want 1", v)
+	}
+	close(replRelease)
+	if v := <-replDone; v != 3 {
+		t.Errorf("replacing call = %d; want 3", v)
+	}
+	if v := <-joined; v != 3 {
+		t.Errorf("joined call = %d; want the fresh result 3", v)
+	}
+}
+
+func TestDoBypassAdmission(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	paused := new(Group[string, int])
+	paused.Pause()
+	if _, _, err := paused.DoBypass(ctx, "key", fn, false); !errors.Is(err, ErrPaused) {
+		t.Errorf("paused DoBypass = %v; want ErrPaused", err)
+	}
+
+	overloaded := NewGroup(WithLoadShedding[string, int](func() bool { return true }))
+	if _, _, err := overloaded.DoBypass(ctx, "key", fn, true); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("overloaded DoBypass = %v; want ErrOverloaded", err)
+	}
+
+	quota := NewGroup(WithIdentityQuota[string, int](1))
+	alice := WithIdentity(ctx, "alice")
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := quota.DoChan(alice, "a", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	if _, _, err := quota.DoBypass(alice, "b", fn, false); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("DoBypass over quota = %v; want ErrQuotaExceeded", err)
+	}
+	close(release)
+	<-ch
+	if _, _, err := quota.DoBypass(alice, "b", fn, false); err != nil {
+		t.Errorf("DoBypass within quota = %v", err)
+	}
+	if tenants := quota.Tenants(); len(tenants) != 0 {
+		t.Errorf("Tenants = %v; want the quota released", tenants)
+	}
+}
@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// BytesFunc writes the result of a BytesGroup call into buf.
+type BytesFunc func(ctx context.Context, buf *bytes.Buffer) error
+
+// BytesGroup is a Group specialized for byte payloads ("fetch a blob").
+// The function writes into a pooled buffer, and every caller sharing the result
+// gets a Lease on that buffer instead of a copy. The buffer returns to the pool
+// once all leases are released, which cuts GC pressure for large payloads.
+type BytesGroup[K comparable] struct {
+	group Group[K, *sharedBuffer]
+	pool  sync.Pool
+}
+
+// sharedBuffer is a pooled buffer shared by several leases.
+type sharedBuffer struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+	pool *sync.Pool
+}
+
+func (b *sharedBuffer) release() {
+	if b.refs.Add(-1) == 0 {
+		b.buf.Reset()
+		b.pool.Put(b.buf)
+	}
+}
+
+// Lease is a read-only view of a shared result of a BytesGroup call.
+type Lease struct {
+	buf      *sharedBuffer
+	released atomic.Bool
+}
+
+// Bytes returns the leased payload. The returned slice is shared with other callers:
+// it must not be modified, and must not be used after Release.
+func (l *Lease) Bytes() []byte {
+	return l.buf.buf.Bytes()
+}
+
+// Release returns the lease. The buffer is recycled when all leases are released.
+// Release is idempotent.
+func (l *Lease) Release() {
+	if l.released.CompareAndSwap(false, true) {
+		l.buf.release()
+	}
+}
+
+// Do executes fn for key like Group.Do and returns a lease on the shared payload.
+// The caller must release the lease when done with it. On error no lease is returned.
+func (g *BytesGroup[K]) Do(ctx context.Context, key K, fn BytesFunc) (lease *Lease, shared bool, err error) {
+	c, leader, callCtx, err := g.group.register(ctx, key, nil, nil, true)
+	if err != nil {
+		return nil, false, err
+	}
+	if !leader {
+		c.wg.Wait()
+		if c.err != nil {
+			return nil, true, c.err
+		}
+		return &Lease{buf: c.val}, true, nil
+	}
+
+	c.onShare = func(dups int) {
+		if c.val != nil {
+			c.val.refs.Store(int32(dups + 1))
+		}
+	}
+	g.group.doCall(callCtx, c, key, func(ctx context.Context) (*sharedBuffer, error) {
+		buf, _ := g.pool.Get().(*bytes.Buffer)
+		if buf == nil {
+			buf = new(bytes.Buffer)
+		}
+		if err := fn(ctx, buf); err != nil {
+			buf.Reset()
+			g.pool.Put(buf)
+			return nil, err
+		}
+		return &sharedBuffer{buf: buf, pool: &g.pool}, nil
+	})
+	if c.err != nil {
+		return nil, c.dups > 0, c.err
+	}
+	return &Lease{buf: c.val}, c.dups > 0, nil
+}
@@ -0,0 +1,138 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBytesGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g BytesGroup[string]
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	const n = 5
+	leases := make([]*Lease, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, _, err := g.Do(ctx, "blob", func(_ context.Context, buf *bytes.Buffer) error {
+				close(started)
+				<-release
+				buf.WriteString("payload")
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do error = %v", err)
+				return
+			}
+			leases[i] = lease
+		}()
+		if i == 0 {
+			<-started
+		}
+	}
+	for g.group.Stats().Shared < n-1 { // wait for all callers to join
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	sb := leases[0].buf
+	for i, lease := range leases {
+		if lease.buf != sb {
+			t.Errorf("lease %d does not share the buffer", i)
+		}
+		if got := string(lease.Bytes()); got != "payload" {
+			t.Errorf("lease %d = %q; want %q", i, got, "payload")
+		}
+	}
+	if got := sb.refs.Load(); got != n {
+		t.Fatalf("references = %d; want %d", got, n)
+	}
+
+	for _, lease := range leases {
+		lease.Release()
+		lease.Release() // idempotent
+	}
+	if got := sb.refs.Load(); got != 0 {
+		t.Errorf("references after release = %d; want 0", got)
+	}
+	if sb.buf.Len() != 0 {
+		t.Error("released buffer must be reset")
+	}
+}
+
+func TestBytesGroupError(t *testing.T) {
+	t.Parallel()
+
+	var g BytesGroup[string]
+	someErr := errors.New("some error")
+	lease, _, err := g.Do(context.Background(), "blob", func(context.Context, *bytes.Buffer) error {
+		return someErr
+	})
+	if lease != nil || !errors.Is(err, someErr) {
+		t.Errorf("Do = %v, %v; want nil, %v", lease, err, someErr)
+	}
+}
+
+func TestBytesGroupPanic(t *testing.T) {
+	t.Parallel()
+
+	var g BytesGroup[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderPanic := make(chan any)
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context, *bytes.Buffer) error {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	follower := make(chan error)
+	go func() {
+		_, _, err := g.Do(context.Background(), "key", func(context.Context, *bytes.Buffer) error { return nil })
+		follower <- err
+	}()
+	for g.group.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if r := <-leaderPanic; r != "boom" {
+		t.Errorf("leader recovered %v; want boom", r)
+	}
+	var perr *PanicError
+	select {
+	case err := <-follower:
+		if !errors.As(err, &perr) {
+			t.Errorf("follower error = %v; want PanicError", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("follower still blocked after the leader panicked")
+	}
+
+	// the key is not wedged
+	lease, _, err := g.Do(context.Background(), "key", func(_ context.Context, buf *bytes.Buffer) error {
+		buf.WriteString("ok")
+		return nil
+	})
+	if err != nil || string(lease.Bytes()) != "ok" {
+		t.Fatalf("Do after panic = %v", err)
+	}
+	lease.Release()
+}
@@ -0,0 +1,192 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheOption configures a Cache created by NewCache.
+type CacheOption[K comparable, V any] func(*Cache[K, V])
+
+// Cache is a read-through cache in front of a Group: a missing or expired value
+// is computed once through singleflight, however many callers ask for it,
+// and then served from memory until its TTL elapses.
+// Errors are not cached.
+type Cache[K comparable, V any] struct {
+	group     *Group[K, V]
+	groupOpts []Option[K, V]
+	ttl       time.Duration
+	size      int
+
+	// early refresh, see WithEarlyRefresh
+	beta float64
+	rand func() float64
+
+	onEvict func(key K, val V, reason EvictReason)
+
+	// snapshot codecs, see WithSnapshotCodecs
+	keyCodec Codec[K]
+	valCodec Codec[V]
+
+	mu      sync.Mutex
+	entries *lru[K, cacheEntry[V]]
+	tags    map[string]map[K]struct{} // keys by tag of their value; lazily initialized
+
+	// dependency graph, see DependsOn; lazily initialized
+	dependents   map[K]map[K]struct{}
+	dependencies map[K]map[K]struct{}
+}
+
+// cacheEntry is a cached value.
+type cacheEntry[V any] struct {
+	val     V
+	started time.Time     // start of the computation of val
+	delta   time.Duration // time it took to compute val
+	tags    []string
+}
+
+// NewCache creates a cache keeping values for ttl. A zero ttl keeps values
+// until they are evicted by size or forgotten.
+func NewCache[K comparable, V any](ttl time.Duration, opts ...CacheOption[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{ttl: ttl}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.group = NewGroup(c.groupOpts...)
+	c.entries = newLRU[K, cacheEntry[V]](c.size)
+	return c
+}
+
+// WithCacheSize bounds the number of cached values.
+// The least recently used values are evicted first.
+func WithCacheSize[K comparable, V any](size int) CacheOption[K, V] {
+	return func(c *Cache[K, V]) {
+		c.size = size
+	}
+}
+
+// WithGroupOptions configures the Group used by the cache to compute values.
+func WithGroupOptions[K comparable, V any](opts ...Option[K, V]) CacheOption[K, V] {
+	return func(c *Cache[K, V]) {
+		c.groupOpts = append(c.groupOpts, opts...)
+	}
+}
+
+// Get returns the cached value for key, computing it with fn if it is missing or expired.
+// Concurrent misses for the same key share one execution of fn.
+func (c *Cache[K, V]) Get(ctx context.Context, key K, fn doFunc[V]) (V, error) {
+	return c.get(ctx, key, time.Time{}, withoutMeta(fn))
+}
+
+// GetFresh is like Get but only accepts a value computed by an execution which started
+// at or after notBefore, for read-your-writes flows: pass the time of the last mutation.
+// Older cached values and in-flight executions are ignored and a new execution is started.
+func (c *Cache[K, V]) GetFresh(ctx context.Context, key K, notBefore time.Time, fn doFunc[V]) (V, error) {
+	return c.get(ctx, key, notBefore, withoutMeta(fn))
+}
+
+// get implements Get, GetFresh and GetMeta, a zero notBefore accepts any value.
+func (c *Cache[K, V]) get(ctx context.Context, key K, notBefore time.Time, fn MetaFunc[V]) (V, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	expired, wasExpired := c.removeExpired(key, now)
+	ent, ok := c.entries.getEntry(key, now)
+	c.mu.Unlock()
+
+	if wasExpired {
+		c.evicted(EvictExpired, expired)
+	}
+	if ok && ent.val.started.Before(notBefore) {
+		ok = false
+	}
+	if ok && !c.refreshEarly(ent, now) {
+		return ent.val.val, nil
+	}
+
+	v, _, err := c.group.DoFresh(ctx, key, notBefore, func(ctx context.Context) (V, error) {
+		start := time.Now()
+		v, meta, err := fn(ctx)
+		if err == nil {
+			c.store(key, v, start, meta)
+		}
+		return v, err
+	})
+	if err != nil && ok {
+		// a failed early refresh keeps serving the still valid value
+		return ent.val.val, nil
+	}
+	return v, err
+}
+
+// Peek returns the cached value for key without computing it.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	expired, wasExpired := c.removeExpired(key, now)
+	ent, ok := c.entries.get(key, now)
+	c.mu.Unlock()
+
+	if wasExpired {
+		c.evicted(EvictExpired, expired)
+	}
+	return ent.val, ok
+}
+
+// Set stores v for key as if it was computed.
+func (c *Cache[K, V]) Set(key K, v V) {
+	c.store(key, v, time.Now(), Meta{})
+}
+
+// Forget removes the cached value for key and the values depending on it, see DependsOn.
+// It does not affect a computation in flight.
+func (c *Cache[K, V]) Forget(key K) {
+	c.mu.Lock()
+	removed := c.invalidate(key, nil, nil)
+	c.mu.Unlock()
+
+	c.evicted(EvictForgotten, removed...)
+}
+
+// Len returns the number of cached values, including expired ones not yet removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.len()
+}
+
+// store caches v for key, computed from started until now.
+func (c *Cache[K, V]) store(key K, v V, started time.Time, meta Meta) {
+	now := time.Now()
+	ttl := c.ttl
+	if meta.TTL > 0 {
+		ttl = meta.TTL
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+
+	c.put(key, cacheEntry[V]{val: v, started: started, delta: now.Sub(started), tags: meta.Tags}, expires)
+}
+
+// put adds ent for key, replacing the current entry.
+func (c *Cache[K, V]) put(key K, ent cacheEntry[V], expires time.Time) {
+	c.mu.Lock()
+	old, replaced := c.remove(key)
+	evicted := c.entries.add(key, ent, expires)
+	c.tag(key, ent.tags)
+	for _, e := range evicted {
+		c.untag(e)
+		c.undepend(e.key)
+	}
+	c.mu.Unlock()
+
+	if replaced {
+		c.evicted(EvictReplaced, old)
+	}
+	c.evicted(EvictSize, evicted...)
+}
@@ -0,0 +1,94 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheGet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](time.Minute)
+
+	var calls atomic.Int32
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get(ctx, "key", fn); v != 42 || err != nil {
+				t.Errorf("Get = %v, %v; want 42, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, err := c.Get(ctx, "key", fn); v != 42 || err != nil {
+		t.Errorf("cached Get = %v, %v; want 42, nil", v, err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("function called %d times; want 1", got)
+	}
+	if v, ok := c.Peek("key"); !ok || v != 42 {
+		t.Errorf("Peek = %v, %v; want 42, true", v, ok)
+	}
+
+	c.Forget("key")
+	if _, ok := c.Peek("key"); ok {
+		t.Error("Peek after Forget must miss")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](10*time.Millisecond, WithCacheSize[string, int](1))
+
+	var calls atomic.Int32
+	fn := func(context.Context) (int, error) {
+		return int(calls.Add(1)), nil
+	}
+
+	if v, _ := c.Get(ctx, "key", fn); v != 1 {
+		t.Errorf("Get = %d; want 1", v)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if v, _ := c.Get(ctx, "key", fn); v != 2 {
+		t.Errorf("Get after expiry = %d; want 2", v)
+	}
+
+	c.Set("other", 10)
+	if _, ok := c.Peek("key"); ok {
+		t.Error("key must be evicted by size")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len = %d; want 1", c.Len())
+	}
+}
+
+func TestCacheErrorNotCached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](time.Minute)
+	someErr := errors.New("some error")
+
+	if _, err := c.Get(ctx, "key", func(context.Context) (int, error) { return 0, someErr }); !errors.Is(err, someErr) {
+		t.Errorf("Get error = %v; want %v", err, someErr)
+	}
+	if v, err := c.Get(ctx, "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Get after error = %v, %v; want 1, nil", v, err)
+	}
+}
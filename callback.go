@@ -0,0 +1,39 @@
+package singleflight
+
+import "context"
+
+// DoCallback is like DoChan but calls onDone with the result instead of sending it
+// on a channel, for event-loop style code which must never block.
+// DoCallback doesn't wait for the result: onDone is called exactly once, by the goroutine
+// completing the call, after the channels of DoChan received the result, so it must not block.
+// The function of a new call always runs in a new goroutine, even with WithSyncLeader.
+// If the call is rejected, e.g. with ErrClosed, onDone is called before DoCallback returns.
+// Like Do, the caller keeps sharing the call when ctx is done.
+func (g *Group[K, V]) DoCallback(ctx context.Context, key K, fn doFunc[V], onDone func(Result[V])) {
+	c, leader, callCtx, resumed, err := g.tryRegister(ctx, key, fn, nil)
+	if err != nil {
+		onDone(Result[V]{Err: err})
+		return
+	}
+	if resumed != nil {
+		go func() { onDone(g.DoDetailed(ctx, key, fn)) }()
+		return
+	}
+	if !leader {
+		g.checkFunc(c, key, fn)
+	}
+
+	g.mu.Lock()
+	if c.isDone() {
+		// the call completed since it was joined
+		g.mu.Unlock()
+		onDone(c.result())
+		return
+	}
+	c.callbacks = append(c.callbacks, onDone)
+	g.mu.Unlock()
+
+	if leader {
+		go g.doCall(callCtx, c, key, fn) // even with WithSyncLeader, since the caller doesn't wait
+	}
+}
@@ -0,0 +1,61 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDoCallback(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	ctx := context.Background()
+	results := make(chan Result[int], 2)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	g.DoCallback(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	}, func(res Result[int]) { results <- res })
+	<-started
+	g.DoCallback(ctx, "key", func(context.Context) (int, error) { return 2, nil }, func(res Result[int]) { results <- res })
+	close(release)
+
+	for range 2 {
+		if res := <-results; res.Val != 1 || res.SharedCount != 2 {
+			t.Errorf("result = %+v; want 1 shared by 2 callers", res)
+		}
+	}
+
+	g.Close()
+	g.DoCallback(ctx, "key", func(context.Context) (int, error) { return 3, nil }, func(res Result[int]) { results <- res })
+	select {
+	case res := <-results:
+		if !errors.Is(res.Err, ErrClosed) {
+			t.Errorf("result after Close = %+v; want ErrClosed", res)
+		}
+	default:
+		t.Error("onDone must be called before DoCallback returns on rejection")
+	}
+}
+
+func TestDoCallbackSyncLeader(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithSyncLeader[string, int]())
+	release := make(chan struct{})
+	results := make(chan Result[int], 1)
+	g.DoCallback(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}, func(res Result[int]) { results <- res })
+
+	// DoCallback returned while the function is still blocked
+	close(release)
+	if res := <-results; res.Val != 1 {
+		t.Errorf("result = %+v; want 1", res)
+	}
+}
@@ -0,0 +1,26 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// callIDs generates the identifiers of executions, unique in the process.
+var callIDs atomic.Uint64
+
+// callIDCtxKey is the context key of the identifier of an execution.
+type callIDCtxKey struct{}
+
+// CallID returns the identifier of the execution running with ctx, or 0 if ctx is not
+// the context of an execution. Every caller sharing the result of the execution finds
+// the same identifier in Result.CallID, so the logs of the function can be correlated
+// with the logs of the callers.
+func CallID(ctx context.Context) uint64 {
+	id, _ := ctx.Value(callIDCtxKey{}).(uint64)
+	return id
+}
+
+// withCallID returns ctx holding the identifier of the call c.
+func withCallID[V any](ctx context.Context, c *call[V]) context.Context {
+	return context.WithValue(ctx, callIDCtxKey{}, c.id)
+}
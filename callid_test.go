@@ -0,0 +1,37 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCallID(t *testing.T) {
+	t.Parallel()
+
+	var (
+		g      Group[string, int]
+		inside uint64
+	)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(context.Background(), "key", func(ctx context.Context) (int, error) {
+		inside = CallID(ctx)
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	joined := g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	close(release)
+
+	first, second := <-leader, <-joined
+	if inside == 0 || first.CallID != inside || second.CallID != inside {
+		t.Errorf("call IDs = %d, %d; want both %d", first.CallID, second.CallID, inside)
+	}
+	if res := g.DoDetailed(context.Background(), "key", func(context.Context) (int, error) { return 3, nil }); res.CallID == inside {
+		t.Error("a new execution must have a new ID")
+	}
+	if id := CallID(context.Background()); id != 0 {
+		t.Errorf("CallID outside an execution = %d; want 0", id)
+	}
+}
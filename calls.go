@@ -0,0 +1,87 @@
+package singleflight
+
+import (
+	"sort"
+	"time"
+)
+
+// CallInfo describes an in-flight call.
+type CallInfo struct {
+	// Key is the key of the call, redacted if the group has a key redactor.
+	Key any `json:"key"`
+	// Started is the time the execution started.
+	Started time.Time `json:"started"`
+	// Dups is the number of callers waiting for the call besides the leader.
+	Dups int `json:"dups"`
+	// Generation is the generation of the call, see Group.Generation.
+	Generation uint64 `json:"generation"`
+	// Forgotten reports whether new callers don't join the call, because the key
+	// was forgotten or the call bypassed the group (see DoBypass).
+	Forgotten bool `json:"forgotten,omitempty"`
+}
+
+// Age returns how long the call has been running at now.
+func (ci CallInfo) Age(now time.Time) time.Duration {
+	return now.Sub(ci.Started)
+}
+
+// Calls returns the calls in progress, oldest first.
+func (g *Group[K, V]) Calls() []CallInfo {
+	g.mu.Lock()
+	calls := g.runningCalls()
+	g.mu.Unlock()
+
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Started.Before(calls[j].Started) })
+	return calls
+}
+
+// runningCalls describes the calls in progress, in no particular order.
+// Must be called with g.mu held.
+func (g *Group[K, V]) runningCalls() []CallInfo {
+	calls := make([]CallInfo, 0, len(g.running))
+	for c, key := range g.running {
+		calls = append(calls, g.callInfo(c, key))
+	}
+	return calls
+}
+
+// callInfo describes the call c for key.
+// Must be called with g.mu held.
+func (g *Group[K, V]) callInfo(c *call[V], key K) CallInfo {
+	return CallInfo{
+		Key:        g.keyLabel(key),
+		Started:    c.started,
+		Dups:       c.dups,
+		Generation: c.gen,
+		Forgotten:  g.m[key] != c,
+	}
+}
+
+// State is a serializable snapshot of a group, see DumpState.
+type State struct {
+	// Taken is the time the snapshot was taken.
+	Taken time.Time `json:"taken"`
+	// Closed reports whether the group is closed.
+	Closed bool `json:"closed"`
+	// Stats are the group counters.
+	Stats Stats `json:"stats"`
+	// Calls are the calls in progress, oldest first.
+	Calls []CallInfo `json:"calls"`
+}
+
+// DumpState returns a snapshot of the group which can be logged or marshaled
+// to JSON for incident tooling. The snapshot is taken at once, so its stats
+// match its calls, e.g. Stats.InFlight is the number of Calls.
+func (g *Group[K, V]) DumpState() State {
+	g.mu.Lock()
+	state := State{
+		Taken:  time.Now(),
+		Closed: g.closed,
+		Stats:  g.snapshotStats(),
+		Calls:  g.runningCalls(),
+	}
+	g.mu.Unlock()
+
+	sort.Slice(state.Calls, func(i, j int) bool { return state.Calls[i].Started.Before(state.Calls[j].Started) })
+	return state
+}
@@ -0,0 +1,115 @@
+package singleflight
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCalls(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	for _, key := range []string{"a", "b"} {
+		started := make(chan struct{})
+		go func(key string) {
+			_, _, _ = g.Do(context.Background(), key, func(context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+		}(key)
+		<-started
+	}
+	go func() {
+		_, _, _ = g.Do(context.Background(), "a", func(context.Context) (int, error) { return 2, nil })
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	g.ForgetUnshared("b")
+
+	calls := g.Calls()
+	close(release)
+
+	if len(calls) != 2 {
+		t.Fatalf("Calls = %v; want 2 calls", calls)
+	}
+	if calls[0].Key != "a" || calls[0].Dups != 1 || calls[0].Forgotten {
+		t.Errorf("calls[0] = %+v; want key a with 1 dup", calls[0])
+	}
+	if calls[1].Key != "b" || calls[1].Dups != 0 || !calls[1].Forgotten {
+		t.Errorf("calls[1] = %+v; want forgotten key b", calls[1])
+	}
+	if calls[0].Age(time.Now()) < calls[1].Age(time.Now()) {
+		t.Error("calls must be sorted oldest first")
+	}
+}
+
+func TestDumpState(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	state := g.DumpState()
+	close(release)
+
+	if state.Closed || state.Stats.InFlight != 1 || len(state.Calls) != 1 || state.Calls[0].Key != "key" {
+		t.Errorf("DumpState = %+v", state)
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"key":"key"`) || !strings.Contains(string(b), `"in_flight":1`) {
+		t.Errorf("JSON = %s", b)
+	}
+}
+
+func TestDumpStateConsistent(t *testing.T) {
+	t.Parallel()
+
+	var g Group[int, int]
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _, _ = g.Do(context.Background(), w*100+i%10, func(context.Context) (int, error) {
+					time.Sleep(time.Microsecond)
+					return i, nil
+				})
+			}
+		}()
+	}
+
+	for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+		if state := g.DumpState(); state.Stats.InFlight != len(state.Calls) {
+			t.Errorf("DumpState with %d calls in flight and %d calls", state.Stats.InFlight, len(state.Calls))
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,110 @@
+package singleflight
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of index bits of the HyperLogLog sketch:
+// 2^10 registers estimate the cardinality with a standard error around 3%.
+const hllPrecision = 10
+
+// hyperLogLog estimates the number of distinct hashes added to it.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+// add adds the hash h.
+func (s *hyperLogLog) add(h uint64) {
+	idx := h >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1))) + 1
+	s.registers[idx] = max(s.registers[idx], rank)
+}
+
+// estimate returns the estimated number of distinct hashes added.
+func (s *hyperLogLog) estimate() uint64 {
+	const m = float64(len(s.registers))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// linear counting is more accurate for small cardinalities
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
+
+// KeyCardinality describes the keys of a window of calls, see WithKeyCardinalityAlert.
+type KeyCardinality struct {
+	// Calls is the number of calls in the window.
+	Calls int
+	// Keys is the estimated number of distinct keys of the calls.
+	Keys uint64
+}
+
+// Ratio returns the estimated share of distinct keys among the calls, at most 1.
+// A ratio close to 1 means calls almost never share a key, so they can hardly be deduplicated.
+func (k KeyCardinality) Ratio() float64 {
+	if k.Calls == 0 {
+		return 0
+	}
+	return min(float64(k.Keys)/float64(k.Calls), 1)
+}
+
+// cardinalityWindow counts the keys of the current window of WithKeyCardinalityAlert.
+type cardinalityWindow struct {
+	seed   maphash.Seed
+	sketch *hyperLogLog // lazily initialized
+	calls  int
+}
+
+// WithKeyCardinalityAlert estimates, with a HyperLogLog sketch, the number of distinct keys
+// over consecutive windows of window calls, and calls fn at the end of every window where
+// their ratio to the calls is at least threshold, e.g. 0.9. Such a ratio means the keys are
+// almost all different, which usually reveals a key construction defeating singleflight,
+// e.g. keys including a timestamp or a request ID.
+func WithKeyCardinalityAlert[K comparable, V any](threshold float64, window int, fn func(KeyCardinality)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.cardinalityThreshold = threshold
+		o.cardinalityWindow = window
+		o.onHighCardinality = fn
+	}
+}
+
+// countKey counts a call for key in the cardinality window and returns
+// the function raising the alert, if any, to call once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) countKey(key K) func() {
+	if g.options().onHighCardinality == nil || g.options().cardinalityWindow <= 0 {
+		return func() {}
+	}
+
+	w := &g.distinct
+	if w.sketch == nil {
+		w.seed = maphash.MakeSeed()
+		w.sketch = &hyperLogLog{}
+	}
+	w.sketch.add(maphash.Comparable(w.seed, key))
+	w.calls++
+	if w.calls < g.options().cardinalityWindow {
+		return func() {}
+	}
+
+	k := KeyCardinality{Calls: w.calls, Keys: w.sketch.estimate()}
+	*w.sketch = hyperLogLog{}
+	w.calls = 0
+	if k.Ratio() < g.options().cardinalityThreshold {
+		return func() {}
+	}
+	fn := g.options().onHighCardinality
+	return func() { fn(k) }
+}
@@ -0,0 +1,51 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	t.Parallel()
+
+	seed := maphash.MakeSeed()
+	for _, n := range []int{0, 100, 10000, 100000} {
+		var s hyperLogLog
+		for i := range n {
+			s.add(maphash.Comparable(seed, i))
+			s.add(maphash.Comparable(seed, i)) // duplicates don't count
+		}
+		if got := float64(s.estimate()); got < 0.85*float64(n) || got > 1.15*float64(n) {
+			t.Errorf("estimate of %d = %v", n, got)
+		}
+	}
+}
+
+func TestKeyCardinalityAlert(t *testing.T) {
+	t.Parallel()
+
+	var alerts []KeyCardinality
+	g := NewGroup(WithKeyCardinalityAlert[string, int](0.9, 100, func(k KeyCardinality) {
+		alerts = append(alerts, k)
+	}))
+	ctx := context.Background()
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	// few keys
+	for i := range 100 {
+		_, _, _ = g.Do(ctx, strconv.Itoa(i%10), fn)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("alerts = %+v; want none for 10 keys", alerts)
+	}
+
+	// unique keys
+	for i := range 100 {
+		_, _, _ = g.Do(ctx, "req-"+strconv.Itoa(i), fn)
+	}
+	if len(alerts) != 1 || alerts[0].Calls != 100 || alerts[0].Ratio() < 0.9 {
+		t.Errorf("alerts = %+v; want one for unique keys", alerts)
+	}
+}
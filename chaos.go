@@ -0,0 +1,82 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrChaos is the default error injected by WithChaos.
+var ErrChaos = errors.New("singleflight: injected fault")
+
+// Chaos configures the faults injected into executions by WithChaos.
+// Rates are probabilities in [0, 1] drawn independently for every execution.
+type Chaos[K comparable] struct {
+	// KeyRate returns the probability that an execution for key is subject to faults at all,
+	// e.g. to target some keys only. Nil means every execution is.
+	KeyRate func(key K) float64
+
+	// DelayRate is the probability to delay the execution by a random time up to MaxDelay.
+	DelayRate float64
+	MaxDelay  time.Duration
+	// PanicRate is the probability to panic instead of executing the function.
+	// The panic is handled like a panic of the function, see Do.
+	PanicRate float64
+	// ErrorRate is the probability to return Err instead of executing the function.
+	ErrorRate float64
+	// Err is the injected error, ErrChaos if nil.
+	Err error
+
+	// Rand returns random numbers in [0, 1), math/rand.Float64 if nil.
+	Rand func() float64
+}
+
+// WithChaos injects random delays, panics and errors into executions, so fallback,
+// retry and handoff paths can be tested under realistic failures.
+// A delay is injected first and ends early if the call context is done,
+// then a panic or an error may replace the execution of the function.
+// It is meant for tests and staging environments.
+func WithChaos[K comparable, V any](chaos Chaos[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.chaos = &chaos
+	}
+}
+
+// withChaos returns fn injecting the faults configured by WithChaos for key.
+func (g *Group[K, V]) withChaos(key K, fn doFunc[V]) doFunc[V] {
+	chaos := g.options().chaos
+	if chaos == nil {
+		return fn
+	}
+	random := rand.Float64 // nolint: gosec
+	if chaos.Rand != nil {
+		random = chaos.Rand
+	}
+
+	return func(ctx context.Context) (V, error) {
+		if chaos.KeyRate != nil && random() >= chaos.KeyRate(key) {
+			return fn(ctx)
+		}
+
+		if chaos.MaxDelay > 0 && random() < chaos.DelayRate {
+			t := time.NewTimer(time.Duration(random() * float64(chaos.MaxDelay)))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+			case <-t.C:
+			}
+		}
+		if random() < chaos.PanicRate {
+			panic(ErrChaos)
+		}
+		if random() < chaos.ErrorRate {
+			var zero V
+			if chaos.Err != nil {
+				return zero, chaos.Err
+			}
+			return zero, ErrChaos
+		}
+		return fn(ctx)
+	}
+}
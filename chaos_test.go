@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+	g := NewGroup(WithChaos[string, int](Chaos[string]{
+		KeyRate: func(key string) float64 {
+			if key == "target" {
+				return 1
+			}
+			return 0
+		},
+		DelayRate: 1,
+		MaxDelay:  20 * time.Millisecond,
+		ErrorRate: 1,
+		Err:       someErr,
+		Rand:      func() float64 { return 0.5 },
+	}))
+
+	start := time.Now()
+	if _, _, err := g.Do(ctx, "target", func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, someErr) {
+		t.Errorf("Do error = %v; want injected %v", err, someErr)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("Do took %v; want an injected delay of 10ms", d)
+	}
+
+	if v, _, err := g.Do(ctx, "other", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do = %v, %v; want 1, nil for a key not targeted", v, err)
+	}
+}
+
+func TestChaosPanic(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithChaos[string, int](Chaos[string]{PanicRate: 1}))
+
+	defer func() {
+		if r := recover(); r != ErrChaos {
+			t.Errorf("recovered %v; want %v", r, ErrChaos)
+		}
+	}()
+	_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	t.Error("Do must panic")
+}
@@ -0,0 +1,82 @@
+package singleflight
+
+// WithMetricsKeyClassifier sets the function mapping keys to a bounded set of classes,
+// e.g. "user:*" or "order:*", so statistics can be reported per key pattern, see ClassStats,
+// without using raw keys as metric labels. The classifier must return few distinct classes:
+// the group keeps counters for each of them.
+func WithMetricsKeyClassifier[K comparable, V any](classify func(K) string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.classify = classify
+	}
+}
+
+// KeyClass returns the class of key set by WithMetricsKeyClassifier, or "" without classifier.
+func (g *Group[K, V]) KeyClass(key K) string {
+	if classify := g.options().classify; classify != nil {
+		return classify(key)
+	}
+	return ""
+}
+
+// ClassStats returns a snapshot of the counters of the group per class of keys,
+// see WithMetricsKeyClassifier. It returns nil without classifier.
+func (g *Group[K, V]) ClassStats() map[string]Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.classes == nil {
+		return nil
+	}
+	out := make(map[string]Stats, len(g.classes))
+	for class, st := range g.classes {
+		out[class] = *st
+	}
+	return out
+}
+
+// classStats returns the counters of class, creating them as needed,
+// or nil if the call is not classified.
+// Must be called with g.mu held.
+func (g *Group[K, V]) classStats(c *call[V]) *Stats {
+	if !c.classified {
+		return nil
+	}
+	if g.classes == nil {
+		g.classes = make(map[string]*Stats)
+	}
+	st, ok := g.classes[c.class]
+	if !ok {
+		st = &Stats{}
+		g.classes[c.class] = st
+	}
+	return st
+}
+
+// countClassCall counts a new call, joined or not, in the class of c.
+// Must be called with g.mu held.
+func (g *Group[K, V]) countClassCall(c *call[V], joined bool) {
+	st := g.classStats(c)
+	if st == nil {
+		return
+	}
+	st.Calls++
+	if joined {
+		st.Shared++
+		return
+	}
+	st.Executions++
+	st.InFlight++
+}
+
+// countClassFinish counts the completion of c in its class.
+// Must be called with g.mu held.
+func (g *Group[K, V]) countClassFinish(c *call[V]) {
+	st := g.classStats(c)
+	if st == nil {
+		return
+	}
+	st.InFlight--
+	if c.err != nil {
+		st.Errors++
+	}
+}
@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClassStats(t *testing.T) {
+	t.Parallel()
+
+	if stats := new(Group[string, int]).ClassStats(); stats != nil {
+		t.Errorf("ClassStats without classifier = %v; want nil", stats)
+	}
+
+	classify := func(key string) string { return strings.SplitN(key, ":", 2)[0] + ":*" }
+	g := NewGroup(WithMetricsKeyClassifier[string, int](classify))
+	ctx := context.Background()
+
+	_, _, _ = g.Do(ctx, "user:1", func(context.Context) (int, error) { return 1, nil })
+	_, _, _ = g.Do(ctx, "user:2", func(context.Context) (int, error) { return 0, errors.New("some error") })
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(ctx, "order:1", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	joined := g.DoChan(ctx, "order:1", func(context.Context) (int, error) { return 2, nil })
+	close(release)
+	<-leader
+	<-joined
+
+	stats := g.ClassStats()
+	if want := (Stats{Calls: 2, Executions: 2, Errors: 1}); stats["user:*"] != want {
+		t.Errorf("user:* stats = %+v; want %+v", stats["user:*"], want)
+	}
+	if want := (Stats{Calls: 2, Executions: 1, Shared: 1}); stats["order:*"] != want {
+		t.Errorf("order:* stats = %+v; want %+v", stats["order:*"], want)
+	}
+	if class := g.KeyClass("user:3"); class != "user:*" {
+		t.Errorf("KeyClass = %q; want user:*", class)
+	}
+}
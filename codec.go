@@ -0,0 +1,24 @@
+package singleflight
+
+import "encoding/json"
+
+// Codec converts values to and from bytes, for results which leave the process.
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec[V any] struct{}
+
+// Marshal implements Codec.
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
@@ -0,0 +1,262 @@
+package singleflight
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the tunables of a group, and of a cache in front of it, as plain values,
+// so deployments can set them from the environment (see FromEnv), command-line flags
+// (see RegisterFlags) or a configuration file instead of code. Zero values leave the
+// corresponding option disabled. Options taking callbacks have no field and are added
+// in code, except the watermark thresholds, see ConfigWatermarks.
+//
+// In JSON, durations are either strings in the time.ParseDuration syntax, e.g. "1s",
+// or integer nanoseconds.
+type Config struct {
+	Name            string `json:"name"`             // see WithName
+	LeaderStacks    bool   `json:"leader_stacks"`    // see WithLeaderStacks
+	InflightProfile bool   `json:"inflight_profile"` // see WithInflightProfile
+	PauseQueueing   bool   `json:"pause_queueing"`   // see WithPauseQueueing
+	CancelAbandoned bool   `json:"cancel_abandoned"` // see WithCancelAbandoned
+	SyncLeader      bool   `json:"sync_leader"`      // see WithSyncLeader
+	FIFOWakeup      bool   `json:"fifo_wakeup"`      // see WithFIFOWakeup
+	DetachedContext bool   `json:"detached_context"` // see WithDetachedContext, without keys
+
+	LastResults    int           `json:"last_results"`     // see WithLastResults
+	LastResultsTTL time.Duration `json:"last_results_ttl"` // see WithLastResults
+
+	FailureCooldown    time.Duration `json:"failure_cooldown"`     // base delay, see WithFailureCooldown
+	FailureCooldownMax time.Duration `json:"failure_cooldown_max"` // see WithFailureCooldown
+
+	LatencyEstimates  int `json:"latency_estimates"`  // see WithLatencyEstimates
+	DeadlineAdmission int `json:"deadline_admission"` // see WithDeadlineAdmission
+	IdentityQuota     int `json:"identity_quota"`     // see WithIdentityQuota
+
+	MaxSubscribers      int  `json:"max_subscribers"`      // see WithMaxSubscribers
+	RedirectSubscribers bool `json:"redirect_subscribers"` // see WithMaxSubscribers
+
+	HighWatermark int `json:"high_watermark"` // see ConfigWatermarks
+	LowWatermark  int `json:"low_watermark"`  // see ConfigWatermarks
+
+	MaxGoroutines int    `json:"max_goroutines"` // shedding threshold, see MaxGoroutines
+	MaxHeap       uint64 `json:"max_heap"`       // shedding threshold in bytes, see MaxHeap
+
+	RetryAttempts int           `json:"retry_attempts"` // see WithRetry
+	RetryBackoff  time.Duration `json:"retry_backoff"`  // see WithRetry
+
+	RecentCalls int           `json:"recent_calls"` // see WithRecentCalls
+	Timeout     time.Duration `json:"timeout"`      // see WithTimeout
+
+	CacheTTL  time.Duration `json:"cache_ttl"`  // see NewCache
+	CacheSize int           `json:"cache_size"` // see WithCacheSize
+}
+
+// define defines the fields of c in fs, with their current values as defaults, under
+// the names returned by name for the upper snake case names of the fields, e.g. CACHE_TTL.
+func (c *Config) define(fs *flag.FlagSet, name func(string) string) {
+	fs.StringVar(&c.Name, name("NAME"), c.Name, "group name, see WithName")
+	fs.BoolVar(&c.LeaderStacks, name("LEADER_STACKS"), c.LeaderStacks, "see WithLeaderStacks")
+	fs.BoolVar(&c.InflightProfile, name("INFLIGHT_PROFILE"), c.InflightProfile, "see WithInflightProfile")
+	fs.BoolVar(&c.PauseQueueing, name("PAUSE_QUEUEING"), c.PauseQueueing, "see WithPauseQueueing")
+	fs.BoolVar(&c.CancelAbandoned, name("CANCEL_ABANDONED"), c.CancelAbandoned, "see WithCancelAbandoned")
+	fs.BoolVar(&c.SyncLeader, name("SYNC_LEADER"), c.SyncLeader, "see WithSyncLeader")
+	fs.BoolVar(&c.FIFOWakeup, name("FIFO_WAKEUP"), c.FIFOWakeup, "see WithFIFOWakeup")
+	fs.BoolVar(&c.DetachedContext, name("DETACHED_CONTEXT"), c.DetachedContext, "see WithDetachedContext")
+	fs.IntVar(&c.LastResults, name("LAST_RESULTS"), c.LastResults, "see WithLastResults")
+	fs.DurationVar(&c.LastResultsTTL, name("LAST_RESULTS_TTL"), c.LastResultsTTL, "see WithLastResults")
+	fs.DurationVar(&c.FailureCooldown, name("FAILURE_COOLDOWN"), c.FailureCooldown, "base delay, see WithFailureCooldown")
+	fs.DurationVar(&c.FailureCooldownMax, name("FAILURE_COOLDOWN_MAX"), c.FailureCooldownMax, "see WithFailureCooldown")
+	fs.IntVar(&c.LatencyEstimates, name("LATENCY_ESTIMATES"), c.LatencyEstimates, "see WithLatencyEstimates")
+	fs.IntVar(&c.DeadlineAdmission, name("DEADLINE_ADMISSION"), c.DeadlineAdmission, "see WithDeadlineAdmission")
+	fs.IntVar(&c.IdentityQuota, name("IDENTITY_QUOTA"), c.IdentityQuota, "see WithIdentityQuota")
+	fs.IntVar(&c.MaxSubscribers, name("MAX_SUBSCRIBERS"), c.MaxSubscribers, "see WithMaxSubscribers")
+	fs.BoolVar(&c.RedirectSubscribers, name("REDIRECT_SUBSCRIBERS"), c.RedirectSubscribers, "see WithMaxSubscribers")
+	fs.IntVar(&c.HighWatermark, name("HIGH_WATERMARK"), c.HighWatermark, "see WithHighWatermark")
+	fs.IntVar(&c.LowWatermark, name("LOW_WATERMARK"), c.LowWatermark, "see WithLowWatermark")
+	fs.IntVar(&c.MaxGoroutines, name("MAX_GOROUTINES"), c.MaxGoroutines, "shedding threshold, see MaxGoroutines")
+	fs.Uint64Var(&c.MaxHeap, name("MAX_HEAP"), c.MaxHeap, "shedding threshold in bytes, see MaxHeap")
+	fs.IntVar(&c.RetryAttempts, name("RETRY_ATTEMPTS"), c.RetryAttempts, "see WithRetry")
+	fs.DurationVar(&c.RetryBackoff, name("RETRY_BACKOFF"), c.RetryBackoff, "see WithRetry")
+	fs.IntVar(&c.RecentCalls, name("RECENT_CALLS"), c.RecentCalls, "see WithRecentCalls")
+	fs.DurationVar(&c.Timeout, name("TIMEOUT"), c.Timeout, "see WithTimeout")
+	fs.DurationVar(&c.CacheTTL, name("CACHE_TTL"), c.CacheTTL, "see NewCache")
+	fs.IntVar(&c.CacheSize, name("CACHE_SIZE"), c.CacheSize, "see WithCacheSize")
+}
+
+// FromEnv returns the configuration read from the environment variables named after
+// the fields of Config in upper snake case with the given prefix, e.g. SF_CACHE_TTL
+// for the prefix "SF_". Durations use the time.ParseDuration syntax.
+// Unset variables keep their zero value. The configuration is validated.
+func FromEnv(prefix string) (Config, error) {
+	var (
+		c    Config
+		errs []error
+	)
+	fs := flag.NewFlagSet(prefix, flag.ContinueOnError)
+	c.define(fs, func(name string) string { return prefix + name })
+	fs.VisitAll(func(f *flag.Flag) {
+		if s, ok := os.LookupEnv(f.Name); ok {
+			if err := f.Value.Set(s); err != nil {
+				errs = append(errs, fmt.Errorf("singleflight: %s=%q: %w", f.Name, s, err))
+			}
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// RegisterFlags defines the fields of c in fs as flags named after the fields in lower
+// kebab case with the given prefix, e.g. -sf-cache-ttl for the prefix "sf-", with the
+// current values of c as defaults. Call Validate after parsing the flags.
+func (c *Config) RegisterFlags(fs *flag.FlagSet, prefix string) {
+	c.define(fs, func(name string) string {
+		return prefix + strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting durations as strings in the
+// time.ParseDuration syntax as well as integer nanoseconds.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	t := reflect.TypeFor[Config]()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		raw, ok := fields[f.Tag.Get("json")]
+		if !ok || f.Type != reflect.TypeFor[time.Duration]() || len(raw) == 0 || raw[0] != '"' {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("singleflight: config %s: %w", f.Tag.Get("json"), err)
+		}
+		fields[f.Tag.Get("json")] = strconv.AppendInt(nil, int64(d), 10)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	type plain Config // without this method
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// Validate reports the invalid values of the configuration.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("singleflight: invalid config: "+format, args...))
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		n    int
+	}{
+		{"LastResults", c.LastResults},
+		{"LatencyEstimates", c.LatencyEstimates},
+		{"DeadlineAdmission", c.DeadlineAdmission},
+		{"IdentityQuota", c.IdentityQuota},
+		{"MaxSubscribers", c.MaxSubscribers},
+		{"HighWatermark", c.HighWatermark},
+		{"LowWatermark", c.LowWatermark},
+		{"MaxGoroutines", c.MaxGoroutines},
+		{"RetryAttempts", c.RetryAttempts},
+		{"RecentCalls", c.RecentCalls},
+		{"CacheSize", c.CacheSize},
+	} {
+		check(f.n >= 0, "%s = %d is negative", f.name, f.n)
+	}
+	for _, f := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"LastResultsTTL", c.LastResultsTTL},
+		{"FailureCooldown", c.FailureCooldown},
+		{"FailureCooldownMax", c.FailureCooldownMax},
+		{"RetryBackoff", c.RetryBackoff},
+		{"Timeout", c.Timeout},
+		{"CacheTTL", c.CacheTTL},
+	} {
+		check(f.d >= 0, "%s = %s is negative", f.name, f.d)
+	}
+	check(c.FailureCooldownMax == 0 || c.FailureCooldownMax >= c.FailureCooldown,
+		"FailureCooldownMax = %s is less than FailureCooldown = %s", c.FailureCooldownMax, c.FailureCooldown)
+	check(c.LastResultsTTL == 0 || c.LastResults > 0, "LastResultsTTL is set without LastResults")
+	check(!c.RedirectSubscribers || c.MaxSubscribers > 0, "RedirectSubscribers is set without MaxSubscribers")
+	check(c.LowWatermark == 0 || c.LowWatermark < c.HighWatermark,
+		"LowWatermark = %d is not less than HighWatermark = %d", c.LowWatermark, c.HighWatermark)
+	return errors.Join(errs...)
+}
+
+// ConfigOptions returns the group options set by cfg.
+func ConfigOptions[K comparable, V any](cfg Config) []Option[K, V] {
+	var opts []Option[K, V]
+	add := func(enabled bool, opt Option[K, V]) {
+		if enabled {
+			opts = append(opts, opt)
+		}
+	}
+
+	add(cfg.Name != "", WithName[K, V](cfg.Name))
+	add(cfg.LeaderStacks, WithLeaderStacks[K, V]())
+	add(cfg.InflightProfile, WithInflightProfile[K, V]())
+	add(cfg.PauseQueueing, WithPauseQueueing[K, V]())
+	add(cfg.CancelAbandoned, WithCancelAbandoned[K, V]())
+	add(cfg.SyncLeader, WithSyncLeader[K, V]())
+	add(cfg.FIFOWakeup, WithFIFOWakeup[K, V]())
+	add(cfg.DetachedContext, WithDetachedContext[K, V]())
+	add(cfg.LastResults > 0, WithLastResults[K, V](cfg.LastResults, cfg.LastResultsTTL))
+	add(cfg.FailureCooldown > 0, WithFailureCooldown[K, V](cfg.FailureCooldown, cfg.FailureCooldownMax))
+	add(cfg.LatencyEstimates > 0, WithLatencyEstimates[K, V](cfg.LatencyEstimates))
+	add(cfg.DeadlineAdmission > 0, WithDeadlineAdmission[K, V](cfg.DeadlineAdmission))
+	add(cfg.IdentityQuota > 0, WithIdentityQuota[K, V](cfg.IdentityQuota))
+	add(cfg.MaxSubscribers > 0, WithMaxSubscribers[K, V](cfg.MaxSubscribers, cfg.RedirectSubscribers))
+	add(cfg.MaxGoroutines > 0, WithLoadShedding[K, V](MaxGoroutines(cfg.MaxGoroutines)))
+	add(cfg.MaxHeap > 0, WithLoadShedding[K, V](MaxHeap(cfg.MaxHeap)))
+	add(cfg.RetryAttempts > 0, WithRetry[K, V](cfg.RetryAttempts, cfg.RetryBackoff))
+	add(cfg.RecentCalls > 0, WithRecentCalls[K, V](cfg.RecentCalls))
+	add(cfg.Timeout > 0, WithTimeout[K, V](cfg.Timeout))
+	return opts
+}
+
+// ConfigWatermarks returns the options calling onHigh and onLow when the number of
+// executions in progress crosses the thresholds of cfg, see WithHighWatermark and
+// WithLowWatermark. It returns no option if cfg has no high watermark.
+func ConfigWatermarks[K comparable, V any](cfg Config, onHigh, onLow func(inFlight int)) []Option[K, V] {
+	if cfg.HighWatermark <= 0 {
+		return nil
+	}
+	return []Option[K, V]{
+		WithHighWatermark[K, V](cfg.HighWatermark, onHigh),
+		WithLowWatermark[K, V](cfg.LowWatermark, onLow),
+	}
+}
+
+// ConfigCacheOptions returns the options of a cache set by cfg, including the options
+// of its group; pass cfg.CacheTTL to NewCache.
+func ConfigCacheOptions[K comparable, V any](cfg Config) []CacheOption[K, V] {
+	opts := []CacheOption[K, V]{WithGroupOptions(ConfigOptions[K, V](cfg)...)}
+	if cfg.CacheSize > 0 {
+		opts = append(opts, WithCacheSize[K, V](cfg.CacheSize))
+	}
+	return opts
+}
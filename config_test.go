@@ -0,0 +1,108 @@
+package singleflight
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFromEnv doesn't run in parallel because it sets environment variables.
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SFTEST_CACHE_TTL", "1m")
+	t.Setenv("SFTEST_CACHE_SIZE", "100")
+	t.Setenv("SFTEST_RETRY_ATTEMPTS", "3")
+	t.Setenv("SFTEST_SYNC_LEADER", "true")
+
+	cfg, err := FromEnv("SFTEST_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CacheTTL != time.Minute || cfg.CacheSize != 100 || cfg.RetryAttempts != 3 || !cfg.SyncLeader {
+		t.Errorf("FromEnv = %+v", cfg)
+	}
+
+	c := NewCache(cfg.CacheTTL, ConfigCacheOptions[string, int](cfg)...)
+	if v, err := c.Get(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Get = %v, %v; want 1, nil", v, err)
+	}
+
+	t.Setenv("SFTEST_CACHE_SIZE", "many")
+	t.Setenv("SFTEST_RETRY_BACKOFF", "-1s")
+	if _, err = FromEnv("SFTEST_"); err == nil || !strings.Contains(err.Error(), "SFTEST_CACHE_SIZE") {
+		t.Errorf("FromEnv error = %v; want an error naming SFTEST_CACHE_SIZE", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("zero Config must be valid: %v", err)
+	}
+	err := Config{RetryBackoff: -time.Second, FailureCooldown: time.Minute, FailureCooldownMax: time.Second}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "RetryBackoff") || !strings.Contains(err.Error(), "FailureCooldownMax") {
+		t.Errorf("Validate = %v; want errors about RetryBackoff and FailureCooldownMax", err)
+	}
+}
+
+func TestConfigRegisterFlags(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{CacheSize: 10}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs, "sf-")
+	if err := fs.Parse([]string{"-sf-cache-ttl=1m", "-sf-fifo-wakeup", "-sf-name=users"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CacheTTL != time.Minute || cfg.CacheSize != 10 || !cfg.FIFOWakeup || cfg.Name != "users" {
+		t.Errorf("flags = %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	t.Parallel()
+
+	var cfg Config
+	err := json.Unmarshal([]byte(`{"cache_ttl": "1m", "timeout": 1000, "max_subscribers": 5}`), &cfg)
+	if err != nil || cfg.CacheTTL != time.Minute || cfg.Timeout != time.Microsecond || cfg.MaxSubscribers != 5 {
+		t.Errorf("Unmarshal = %+v, %v", cfg, err)
+	}
+	if err := json.Unmarshal([]byte(`{"cache_ttl": "soon"}`), &cfg); err == nil {
+		t.Error("Unmarshal of an invalid duration succeeded")
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Config
+	if err := json.Unmarshal(data, &again); err != nil || again != cfg {
+		t.Errorf("round trip = %+v, %v; want %+v", again, err, cfg)
+	}
+}
+
+func TestConfigWatermarks(t *testing.T) {
+	t.Parallel()
+
+	if opts := ConfigWatermarks[string, int](Config{}, nil, nil); opts != nil {
+		t.Errorf("ConfigWatermarks without thresholds = %d options; want none", len(opts))
+	}
+	var high atomic.Int32
+	cfg := Config{HighWatermark: 1}
+	g := NewGroup(append(ConfigOptions[string, int](cfg),
+		ConfigWatermarks[string, int](cfg, func(int) { high.Add(1) }, nil)...)...)
+	_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	if high.Load() != 1 {
+		t.Errorf("high watermark notifications = %d; want 1", high.Load())
+	}
+	if err := (Config{LowWatermark: 2, HighWatermark: 1}).Validate(); err == nil {
+		t.Error("Validate accepted LowWatermark above HighWatermark")
+	}
+}
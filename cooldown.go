@@ -0,0 +1,123 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrCoolingDown is matched by the errors returned while a failing key is cooling down,
+// see WithFailureCooldown.
+var ErrCoolingDown = errors.New("singleflight: key is cooling down")
+
+// cooldown is the failure state of a key.
+type cooldown struct {
+	failures int
+	until    time.Time
+	delay    time.Duration // of the last cooldown
+	err      error         // last failure
+}
+
+// WithFailureCooldown makes a key which failed rest before it is executed again:
+// after n consecutive failures, calls return an error matching ErrCoolingDown and
+// wrapping the last failure for base * 2^(n-1), at most maxDelay, instead of executing;
+// a maxDelay of 0 means no cap. A success resets the key, and so does resting after the
+// cooldown for maxDelay, or for the last delay without a cap: the state of keys which
+// aren't called again is reclaimed lazily.
+// Context cancellations are not counted as failures.
+// It is a lighter-weight cousin of a circuit breaker, per key.
+func WithFailureCooldown[K comparable, V any](base, maxDelay time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.cooldownBase = base
+		o.cooldownMax = maxDelay
+	}
+}
+
+// checkCooldown returns an error if key is cooling down at now.
+// Must be called with g.mu held.
+func (g *Group[K, V]) checkCooldown(key K, now time.Time) error {
+	cd, ok := g.cooldowns[key]
+	if !ok {
+		return nil
+	}
+	if now.Before(cd.until) {
+		return fmt.Errorf("%w until %s: %w", ErrCoolingDown, cd.until.Format(time.RFC3339Nano), cd.err)
+	}
+	if g.rested(cd, now) {
+		delete(g.cooldowns, key)
+	}
+	return nil
+}
+
+// rested reports whether the key of cd rested long enough after its cooldown
+// to be considered healthy again.
+func (g *Group[K, V]) rested(cd *cooldown, now time.Time) bool {
+	rest := g.options().cooldownMax
+	if rest <= 0 {
+		rest = cd.delay
+	}
+	return now.Sub(cd.until) > rest
+}
+
+// sweepCooldowns deletes the state of the rested keys, at most once per maximal delay,
+// or per base delay without a cap, so keys which fail once and are never called again
+// don't accumulate.
+// Must be called with g.mu held.
+func (g *Group[K, V]) sweepCooldowns(now time.Time) {
+	every := g.options().cooldownMax
+	if every <= 0 {
+		every = g.options().cooldownBase
+	}
+	if now.Sub(g.swept) < every {
+		return
+	}
+	g.swept = now
+	for key, cd := range g.cooldowns {
+		if g.rested(cd, now) {
+			delete(g.cooldowns, key)
+		}
+	}
+}
+
+// recordOutcome updates the failure state of key after an execution which returned err.
+// Must be called with g.mu held.
+func (g *Group[K, V]) recordOutcome(key K, err error) {
+	if g.options().cooldownBase <= 0 {
+		return
+	}
+	if err == nil {
+		delete(g.cooldowns, key)
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if g.cooldowns == nil {
+		g.cooldowns = make(map[K]*cooldown)
+	}
+	cd, ok := g.cooldowns[key]
+	if !ok {
+		g.sweepCooldowns(time.Now())
+		cd = &cooldown{}
+		g.cooldowns[key] = cd
+	}
+	cd.failures++
+	cd.err = err
+
+	maxDelay := g.options().cooldownMax
+	if maxDelay <= 0 {
+		maxDelay = math.MaxInt64 / 2 // no cap, short of overflowing
+	}
+	d := g.options().cooldownBase
+	for i := 1; i < cd.failures && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	cd.delay = d
+	cd.until = time.Now().Add(d)
+}
@@ -0,0 +1,83 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailureCooldown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithFailureCooldown[string, int](20*time.Millisecond, time.Second))
+	someErr := errors.New("some error")
+
+	var calls int
+	fail := func(context.Context) (int, error) {
+		calls++
+		return 0, someErr
+	}
+
+	if _, _, err := g.Do(ctx, "key", fail); !errors.Is(err, someErr) || errors.Is(err, ErrCoolingDown) {
+		t.Fatalf("first error = %v; want %v", err, someErr)
+	}
+	_, _, err := g.Do(ctx, "key", fail)
+	if !errors.Is(err, ErrCoolingDown) || !errors.Is(err, someErr) {
+		t.Errorf("error while cooling down = %v; want ErrCoolingDown wrapping %v", err, someErr)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d; want 1 while cooling down", calls)
+	}
+
+	// second failure doubles the cooldown
+	time.Sleep(25 * time.Millisecond)
+	_, _, _ = g.Do(ctx, "key", fail)
+	time.Sleep(25 * time.Millisecond)
+	if _, _, err = g.Do(ctx, "key", fail); !errors.Is(err, ErrCoolingDown) {
+		t.Errorf("error = %v; want ErrCoolingDown after the second failure", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if v, _, err := g.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do after cooldown = %v, %v; want 1, nil", v, err)
+	}
+	_, _, _ = g.Do(ctx, "key", fail)
+	if calls != 3 {
+		t.Errorf("calls = %d; a success must reset the cooldown", calls)
+	}
+}
+
+func TestFailureCooldownReclaimed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithFailureCooldown[string, int](time.Millisecond, 5*time.Millisecond))
+	fail := func(context.Context) (int, error) { return 0, errors.New("some error") }
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, _ = g.Do(ctx, key, fail)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_, _, _ = g.Do(ctx, "d", fail)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if n := len(g.cooldowns); n != 1 {
+		t.Errorf("cooldowns = %d; want only the last failing key", n)
+	}
+}
+
+func TestFailureCooldownUncapped(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithFailureCooldown[string, int](time.Minute, 0))
+	fail := func(context.Context) (int, error) { return 0, errors.New("some error") }
+
+	_, _, _ = g.Do(ctx, "key", fail)
+	if _, _, err := g.Do(ctx, "key", fail); !errors.Is(err, ErrCoolingDown) {
+		t.Errorf("error = %v; want ErrCoolingDown without a maximal delay", err)
+	}
+}
@@ -0,0 +1,159 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// WithCostBudget limits the total cost of the executions running concurrently to budget,
+// so a few very expensive keys can't monopolize downstream capacity even when their calls
+// are deduplicated. estimate returns the cost of an execution for key, at least 1; a cost
+// above budget is reduced to budget, so the execution runs alone. An execution which would
+// exceed the budget waits, in order of arrival, until enough cost is released, or fails with
+// the error of its context; callers keep joining it meanwhile. The function may report
+// its actual cost once known, see ReportCost.
+func WithCostBudget[K comparable, V any](budget int64, estimate func(K) int64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.costBudget = budget
+		o.estimateCost = estimate
+	}
+}
+
+// costCtxKey is the context key of the cost held by an execution.
+type costCtxKey struct{}
+
+// costHold is the cost held by an execution.
+type costHold struct {
+	budget   *costBudget
+	n        int64 // protected by budget.mu
+	released bool  // the execution completed, protected by budget.mu
+}
+
+// ReportCost replaces the cost held by the execution running with ctx, see WithCostBudget,
+// with its actual cost, once the function knows it, e.g. after reading the size of a response.
+// A lower cost is released at once to waiting executions, a higher cost is taken without
+// waiting, possibly exceeding the budget until the execution completes.
+// It has no effect outside an execution of a group with a cost budget, including after
+// the execution completed, e.g. from a goroutine which outlives it.
+func ReportCost(ctx context.Context, cost int64) {
+	hold, ok := ctx.Value(costCtxKey{}).(*costHold)
+	if !ok {
+		return
+	}
+	b := hold.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hold.released {
+		return
+	}
+	b.used += max(cost, 0) - hold.n
+	hold.n = max(cost, 0)
+	b.wakeUp()
+}
+
+// costWaiter is an execution waiting for cost.
+type costWaiter struct {
+	n     int64
+	ready chan struct{} // closed when the cost is acquired
+}
+
+// costBudget is a weighted semaphore of the cost of the executions.
+type costBudget struct {
+	mu      sync.Mutex
+	size    int64
+	used    int64
+	waiters []*costWaiter
+}
+
+// acquire waits until n is available, or ctx is done.
+func (b *costBudget) acquire(ctx context.Context, n int64) error {
+	b.mu.Lock()
+	if len(b.waiters) == 0 && b.used+n <= b.size {
+		b.used += n
+		b.mu.Unlock()
+		return nil
+	}
+	w := &costWaiter{n: n, ready: make(chan struct{})}
+	b.waiters = append(b.waiters, w)
+	b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-w.ready:
+		// acquired meanwhile
+		b.used -= n
+	default:
+		for i, other := range b.waiters {
+			if other == w {
+				b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+	b.wakeUp()
+	return ctx.Err()
+}
+
+// release releases the cost held by hold.
+func (b *costBudget) release(hold *costHold) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.used -= hold.n
+	hold.n = 0
+	hold.released = true
+	b.wakeUp()
+}
+
+// wakeUp grants their cost to the waiters in order while it fits in the budget.
+// Must be called with b.mu held.
+func (b *costBudget) wakeUp() {
+	for len(b.waiters) > 0 && b.used+b.waiters[0].n <= b.size {
+		w := b.waiters[0]
+		b.waiters[0] = nil
+		b.waiters = b.waiters[1:]
+		b.used += w.n
+		close(w.ready)
+	}
+}
+
+// withCost wraps fn to hold its cost for key while it runs, see WithCostBudget.
+func (g *Group[K, V]) withCost(key K, fn doFunc[V]) doFunc[V] {
+	opts := g.options()
+	if opts.costBudget <= 0 || opts.estimateCost == nil {
+		return fn
+	}
+
+	return func(ctx context.Context) (V, error) {
+		b := g.costs(opts.costBudget)
+		n := min(max(opts.estimateCost(key), 1), opts.costBudget)
+		if err := b.acquire(ctx, n); err != nil {
+			var zero V
+			return zero, err
+		}
+		hold := &costHold{budget: b, n: n}
+		defer b.release(hold)
+
+		return fn(context.WithValue(ctx, costCtxKey{}, hold))
+	}
+}
+
+// costs returns the cost budget of the group, resized to size.
+func (g *Group[K, V]) costs(size int64) *costBudget {
+	b := &g.cost
+	b.mu.Lock()
+	if b.size != size {
+		b.size = size
+		b.wakeUp()
+	}
+	b.mu.Unlock()
+	return b
+}
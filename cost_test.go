@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCostBudget(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithCostBudget[string, int](3, func(key string) int64 {
+		if key == "big" {
+			return 10 // reduced to the budget
+		}
+		return 1
+	}))
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	reported := make(chan struct{})
+	started := make(chan struct{})
+	big := g.DoChan(ctx, "big", func(ctx context.Context) (int, error) {
+		close(started)
+		<-reported
+		ReportCost(ctx, 2)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	// the budget is used up by big
+	small := g.DoChan(ctx, "small", func(context.Context) (int, error) { return 2, nil })
+	select {
+	case res := <-small:
+		t.Fatalf("small completed with %+v while the budget is used up", res)
+	case <-time.After(20 * time.Millisecond):
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := g.Do(timeoutCtx, "other", func(context.Context) (int, error) { return 3, nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do = %v; want DeadlineExceeded while waiting for the budget", err)
+	}
+
+	// big reports a lower cost, releasing room for small
+	close(reported)
+	if res := <-small; res.Val != 2 {
+		t.Errorf("small = %+v; want 2", res)
+	}
+	close(release)
+	if res := <-big; res.Val != 1 {
+		t.Errorf("big = %+v; want 1", res)
+	}
+	if g.cost.used != 0 || len(g.cost.waiters) != 0 {
+		t.Errorf("budget used = %d with %d waiters; want all released", g.cost.used, len(g.cost.waiters))
+	}
+}
+
+func TestReportCostAfterRelease(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithCostBudget[string, int](3, func(string) int64 { return 1 }))
+	var execCtx context.Context
+	_, _, _ = g.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+		execCtx = ctx
+		return 1, nil
+	})
+
+	ReportCost(execCtx, 3) // from a goroutine outliving the execution
+	g.cost.mu.Lock()
+	defer g.cost.mu.Unlock()
+	if g.cost.used != 0 {
+		t.Errorf("budget used = %d after the execution; want 0", g.cost.used)
+	}
+}
@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCycle is matched by errors returned when a call would wait for its own result.
+var ErrCycle = errors.New("singleflight: call cycle detected")
+
+// CycleError is returned when the function for a key, directly or through other
+// singleflight calls, calls Do for the same key of the same group again.
+// Without detection such a call would deadlock.
+// Detection relies on the context passed to the function being propagated to nested calls.
+type CycleError struct {
+	// Path lists the keys of the chain of calls, starting and ending with the repeated key.
+	// Keys of groups with a key redactor are redacted, see WithKeyRedactor.
+	Path []any
+}
+
+// Error implements the error interface.
+func (e *CycleError) Error() string {
+	keys := make([]string, 0, len(e.Path))
+	for _, key := range e.Path {
+		keys = append(keys, fmt.Sprint(key))
+	}
+	return ErrCycle.Error() + ": " + strings.Join(keys, " -> ")
+}
+
+// Is reports whether target is ErrCycle.
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// chainCtxKey is the context key of the chain of calls leading to a leader.
+type chainCtxKey struct{}
+
+// chainLink is an element of the chain of nested calls, from the innermost to the outermost.
+type chainLink struct {
+	parent *chainLink
+	group  any
+	key    any
+	label  any // key as shown in errors
+}
+
+// withChain returns a leader context which records the call of key in group.
+// label is the key as shown in errors.
+func withChain(ctx context.Context, group, key, label any) context.Context {
+	parent, _ := ctx.Value(chainCtxKey{}).(*chainLink)
+	return context.WithValue(ctx, chainCtxKey{}, &chainLink{parent: parent, group: group, key: key, label: label})
+}
+
+// checkCycle returns a CycleError if ctx belongs to a call chain which already contains key in group.
+// label is the key as shown in errors.
+func checkCycle(ctx context.Context, group, key, label any) error {
+	link, _ := ctx.Value(chainCtxKey{}).(*chainLink)
+	for l := link; l != nil; l = l.parent {
+		if l.group != group || l.key != key {
+			continue
+		}
+
+		var path []any
+		for p := link; p != l; p = p.parent {
+			path = append(path, p.label)
+		}
+		path = append(path, l.label)
+		// reverse to get the order of calls
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		return &CycleError{Path: append(path, label)}
+	}
+	return nil
+}
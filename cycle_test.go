@@ -0,0 +1,59 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCycle(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, string]
+	// a -> b -> c -> a
+	next := map[string]string{"a": "b", "b": "c", "c": "a"}
+	var call func(ctx context.Context, key string) (string, error)
+	call = func(ctx context.Context, key string) (string, error) {
+		v, _, err := g.Do(ctx, key, func(ctx context.Context) (string, error) {
+			return call(ctx, next[key])
+		})
+		return v, err
+	}
+
+	_, err := call(context.Background(), "a")
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Do error = %v; want %v", err, ErrCycle)
+	}
+
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("Do error = %T; want *CycleError", err)
+	}
+	want := []any{"a", "b", "c", "a"}
+	if len(cycleErr.Path) != len(want) {
+		t.Fatalf("cycle path = %v; want %v", cycleErr.Path, want)
+	}
+	for i := range want {
+		if cycleErr.Path[i] != want[i] {
+			t.Fatalf("cycle path = %v; want %v", cycleErr.Path, want)
+		}
+	}
+	if got, want := err.Error(), "singleflight: call cycle detected: a -> b -> c -> a"; got != want {
+		t.Errorf("error text = %q; want %q", got, want)
+	}
+}
+
+func TestNoCycleAcrossGroups(t *testing.T) {
+	t.Parallel()
+
+	var g1, g2 Group[string, int]
+	v, _, err := g1.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+		v, _, err := g2.Do(ctx, "key", func(context.Context) (int, error) {
+			return 1, nil
+		})
+		return v + 1, err
+	})
+	if v != 2 || err != nil {
+		t.Errorf("Do = %v, %v; want 2, nil", v, err)
+	}
+}
@@ -0,0 +1,211 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoValue is returned by DataLoader.Load when the batch function returned no value for a key.
+var ErrNoValue = errors.New("singleflight: batch returned no value")
+
+// BatchFunc loads the values of several keys at once.
+// Keys missing from the returned map fail with ErrNoValue.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// DataLoaderOption configures a DataLoader created by NewDataLoader.
+type DataLoaderOption[K comparable, V any] func(*DataLoader[K, V])
+
+// DataLoader combines batching, caching and duplicate suppression in the style of
+// the GraphQL dataloader: keys loaded within a short window are fetched with one call
+// of the batch function, each key is fetched at most once at a time, and loaded
+// values are cached. A DataLoader is meant to be bound to the scope of one request,
+// so its cache never serves stale data across requests; create one per request.
+type DataLoader[K comparable, V any] struct {
+	batch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+	group    Group[K, V]
+
+	mu      sync.Mutex
+	cache   map[K]V
+	pending *loaderBatch[K, V]
+}
+
+// loaderBatch is a set of keys loaded with one call of the batch function.
+type loaderBatch[K comparable, V any] struct {
+	ctx  context.Context
+	keys []K
+	once sync.Once
+	done chan struct{}
+
+	// written once before done is closed
+	values map[K]V
+	err    error
+}
+
+// NewDataLoader creates a DataLoader fetching values with batch.
+func NewDataLoader[K comparable, V any](batch BatchFunc[K, V], opts ...DataLoaderOption[K, V]) *DataLoader[K, V] {
+	l := &DataLoader[K, V]{
+		batch: batch,
+		wait:  time.Millisecond,
+		cache: make(map[K]V),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithBatchWait sets how long keys are collected before the batch is fetched. The default is 1ms.
+func WithBatchWait[K comparable, V any](wait time.Duration) DataLoaderOption[K, V] {
+	return func(l *DataLoader[K, V]) {
+		l.wait = wait
+	}
+}
+
+// WithMaxBatch limits the number of keys in one batch. A full batch is fetched immediately.
+func WithMaxBatch[K comparable, V any](size int) DataLoaderOption[K, V] {
+	return func(l *DataLoader[K, V]) {
+		l.maxBatch = size
+	}
+}
+
+// WithLoaderGroupOptions configures the Group used by the loader to deduplicate keys,
+// e.g. with WithKeyRedactor for the keys named by its errors.
+func WithLoaderGroupOptions[K comparable, V any](opts ...Option[K, V]) DataLoaderOption[K, V] {
+	return func(l *DataLoader[K, V]) {
+		l.group.UpdateOptions(opts...)
+	}
+}
+
+// Load returns the value for key, from the cache or by adding key to the next batch.
+// The batch function is called with the context of the first Load of the batch.
+func (l *DataLoader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	v, ok := l.cache[key]
+	l.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, _, err := l.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		b := l.enqueue(ctx, key)
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+
+		if b.err != nil {
+			var zero V
+			return zero, b.err
+		}
+		v, ok := b.values[key]
+		if !ok {
+			return v, fmt.Errorf("%w for key %v", ErrNoValue, l.group.keyLabel(key))
+		}
+
+		l.mu.Lock()
+		l.cache[key] = v
+		l.mu.Unlock()
+		return v, nil
+	})
+	return v, err
+}
+
+// LoadMany loads several keys, usually in one batch. It returns the values of the keys
+// which were loaded and the first error.
+func (l *DataLoader[K, V]) LoadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	type result struct {
+		key K
+		v   V
+		err error
+	}
+	results := make(chan result, len(keys))
+	for _, key := range keys {
+		key := key
+		go func() {
+			v, err := l.Load(ctx, key)
+			results <- result{key, v, err}
+		}()
+	}
+
+	values := make(map[K]V, len(keys))
+	var firstErr error
+	for range keys {
+		res := <-results
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		values[res.key] = res.v
+	}
+	return values, firstErr
+}
+
+// Prime stores v for key unless key is already cached, and reports whether it was stored.
+func (l *DataLoader[K, V]) Prime(key K, v V) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return false
+	}
+	l.cache[key] = v
+	return true
+}
+
+// Clear removes key from the cache.
+func (l *DataLoader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.cache, key)
+}
+
+// ClearAll empties the cache.
+func (l *DataLoader[K, V]) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache = make(map[K]V)
+}
+
+// enqueue adds key to the pending batch, starting a new batch if necessary.
+func (l *DataLoader[K, V]) enqueue(ctx context.Context, key K) *loaderBatch[K, V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.pending
+	if b == nil {
+		b = &loaderBatch[K, V]{ctx: ctx, done: make(chan struct{})}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	b.keys = append(b.keys, key)
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.dispatch(b)
+	}
+	return b
+}
+
+// dispatch fetches the batch b, once.
+func (l *DataLoader[K, V]) dispatch(b *loaderBatch[K, V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		b.values, b.err = l.batch(b.ctx, b.keys)
+		close(b.done)
+	})
+}
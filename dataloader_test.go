@@ -0,0 +1,119 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingBatch returns a batch function squaring its keys and recording the batches.
+func recordingBatch(mu *sync.Mutex, batches *[][]int) BatchFunc[int, int] {
+	return func(_ context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		sorted := append([]int(nil), keys...)
+		sort.Ints(sorted)
+		*batches = append(*batches, sorted)
+		mu.Unlock()
+
+		values := make(map[int]int, len(keys))
+		for _, k := range keys {
+			if k >= 0 {
+				values[k] = k * k
+			}
+		}
+		return values, nil
+	}
+}
+
+func TestDataLoader(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	l := NewDataLoader(recordingBatch(&mu, &batches), WithBatchWait[int, int](10*time.Millisecond))
+
+	values, err := l.LoadMany(ctx, []int{1, 2, 3, 2, 1})
+	if err != nil {
+		t.Fatalf("LoadMany error = %v", err)
+	}
+	if len(values) != 3 || values[1] != 1 || values[2] != 4 || values[3] != 9 {
+		t.Errorf("LoadMany = %v; want map[1:1 2:4 3:9]", values)
+	}
+
+	// cached
+	if v, err := l.Load(ctx, 3); v != 9 || err != nil {
+		t.Errorf("Load(3) = %v, %v; want 9, nil", v, err)
+	}
+
+	mu.Lock()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("batches = %v; want one batch of 3 keys", batches)
+	}
+	mu.Unlock()
+
+	if _, err = l.Load(ctx, -1); !errors.Is(err, ErrNoValue) {
+		t.Errorf("Load(-1) error = %v; want %v", err, ErrNoValue)
+	}
+}
+
+func TestDataLoaderPrimeClear(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	l := NewDataLoader(recordingBatch(&mu, &batches))
+
+	if !l.Prime(5, 100) {
+		t.Error("Prime of a missing key must store it")
+	}
+	if l.Prime(5, 200) {
+		t.Error("Prime of a cached key must not replace it")
+	}
+	if v, _ := l.Load(ctx, 5); v != 100 {
+		t.Errorf("Load(5) = %d; want primed 100", v)
+	}
+
+	l.Clear(5)
+	if v, _ := l.Load(ctx, 5); v != 25 {
+		t.Errorf("Load(5) after Clear = %d; want 25", v)
+	}
+
+	l.ClearAll()
+	_, _ = l.Load(ctx, 5)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 {
+		t.Errorf("batches = %v; want 2", batches)
+	}
+}
+
+func TestDataLoaderMaxBatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	l := NewDataLoader(recordingBatch(&mu, &batches),
+		WithBatchWait[int, int](time.Hour), WithMaxBatch[int, int](2))
+
+	if _, err := l.LoadMany(context.Background(), []int{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 {
+		t.Errorf("batches = %v; want 2 full batches", batches)
+	}
+}
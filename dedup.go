@@ -0,0 +1,45 @@
+package singleflight
+
+// dedupWindow counts the calls of the current window of WithDedupRatioAlert.
+type dedupWindow struct {
+	calls  int
+	shared int
+}
+
+// WithDedupRatioAlert computes the dedup ratio, the share of calls which joined an in-flight
+// call instead of executing, over consecutive windows of window calls, and calls fn with it
+// at the end of every window where it is below threshold. A low ratio signals that keys are
+// too fine-grained or that traffic patterns changed, so singleflight no longer pays off.
+func WithDedupRatioAlert[K comparable, V any](threshold float64, window int, fn func(ratio float64)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.dedupThreshold = threshold
+		o.dedupWindow = window
+		o.onLowDedup = fn
+	}
+}
+
+// countDedup counts an accepted call, shared or not, in the dedup window and returns
+// the function raising the alert, if any, to call once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) countDedup(shared bool) func() {
+	if g.options().onLowDedup == nil || g.options().dedupWindow <= 0 {
+		return func() {}
+	}
+
+	w := &g.dedup
+	w.calls++
+	if shared {
+		w.shared++
+	}
+	if w.calls < g.options().dedupWindow {
+		return func() {}
+	}
+
+	ratio := float64(w.shared) / float64(w.calls)
+	*w = dedupWindow{}
+	if ratio >= g.options().dedupThreshold {
+		return func() {}
+	}
+	fn := g.options().onLowDedup
+	return func() { fn(ratio) }
+}
@@ -0,0 +1,45 @@
+package singleflight
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestDedupRatioAlert(t *testing.T) {
+	t.Parallel()
+
+	var ratios []float64
+	g := NewGroup(WithDedupRatioAlert[string, int](0.5, 4, func(ratio float64) {
+		ratios = append(ratios, ratio)
+	}))
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	// a window of unique keys
+	for i := range 4 {
+		_, _, _ = g.Do(context.Background(), strconv.Itoa(i), fn)
+	}
+	if len(ratios) != 1 || ratios[0] != 0 {
+		t.Fatalf("ratios = %v; want [0]", ratios)
+	}
+
+	// a window with 3 callers sharing one execution
+	release := make(chan struct{})
+	started := make(chan struct{})
+	chans := []<-chan Result[int]{g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})}
+	<-started
+	for range 3 {
+		chans = append(chans, g.DoChan(context.Background(), "key", fn))
+	}
+	close(release)
+	for _, ch := range chans {
+		<-ch
+	}
+	if len(ratios) != 1 {
+		t.Errorf("ratios = %v; want no alert for a ratio of 0.75", ratios)
+	}
+}
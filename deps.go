@@ -0,0 +1,76 @@
+package singleflight
+
+// DependsOn declares that the value of key was derived from the values of deps:
+// forgetting or invalidating any of deps also removes key, transitively.
+// Cycles are allowed and invalidated once. The declarations of key are dropped whenever
+// its value is removed: invalidated, expired, evicted by size or replaced, so they must be
+// made after the value is stored, e.g. once Get returns, and repeated when it is computed
+// again. Expiration and eviction by size don't cascade.
+func (c *Cache[K, V]) DependsOn(key K, deps ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dependents == nil {
+		c.dependents = make(map[K]map[K]struct{})
+		c.dependencies = make(map[K]map[K]struct{})
+	}
+	for _, dep := range deps {
+		if dep == key {
+			continue
+		}
+		link(c.dependents, dep, key)
+		link(c.dependencies, key, dep)
+	}
+}
+
+// invalidate removes the value of key and of its dependents, transitively, appending
+// the removed entries to removed. visited protects against cycles and may be nil.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) invalidate(key K, visited map[K]struct{}, removed []lruEntry[K, cacheEntry[V]]) []lruEntry[K, cacheEntry[V]] {
+	if visited == nil {
+		visited = make(map[K]struct{})
+	}
+	if _, ok := visited[key]; ok {
+		return removed
+	}
+	visited[key] = struct{}{}
+
+	if ent, ok := c.remove(key); ok {
+		removed = append(removed, ent)
+	}
+	for dependent := range c.dependents[key] {
+		removed = c.invalidate(dependent, visited, removed)
+	}
+
+	c.undepend(key)
+	delete(c.dependents, key)
+	return removed
+}
+
+// undepend drops the declarations of key, see DependsOn.
+// The declarations depending on key are kept while their keys are cached.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) undepend(key K) {
+	for dep := range c.dependencies[key] {
+		unlink(c.dependents, dep, key)
+	}
+	delete(c.dependencies, key)
+}
+
+// link adds to to the set of from in graph.
+func link[K comparable](graph map[K]map[K]struct{}, from, to K) {
+	set, ok := graph[from]
+	if !ok {
+		set = make(map[K]struct{})
+		graph[from] = set
+	}
+	set[to] = struct{}{}
+}
+
+// unlink removes to from the set of from in graph.
+func unlink[K comparable](graph map[K]map[K]struct{}, from, to K) {
+	delete(graph[from], to)
+	if len(graph[from]) == 0 {
+		delete(graph, from)
+	}
+}
@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheDependsOn(t *testing.T) {
+	t.Parallel()
+
+	c := NewCache[string, int](time.Minute)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Set(key, i)
+	}
+	c.DependsOn("a", "b", "c") // a is derived from b and c
+	c.DependsOn("b", "d")
+	c.DependsOn("d", "a") // cycle
+
+	c.Forget("c")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if _, ok := c.Peek(key); ok {
+			t.Errorf("%s must be invalidated by the cascade", key)
+		}
+	}
+	if len(c.dependents) != 0 || len(c.dependencies) != 0 {
+		t.Errorf("graph = %v, %v; want empty", c.dependents, c.dependencies)
+	}
+
+	c.Set("x", 1)
+	c.Set("y", 2)
+	c.DependsOn("x", "y")
+	c.Forget("x")
+	if _, ok := c.Peek("y"); !ok {
+		t.Error("invalidation must not cascade to dependencies")
+	}
+}
+
+func TestCacheDependsOnReclaimed(t *testing.T) {
+	t.Parallel()
+
+	c := NewCache(10*time.Millisecond, WithCacheSize[string, int](2))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.DependsOn("a", "b", "other")
+	c.Set("c", 3) // evicts a
+	if len(c.dependents) != 0 || len(c.dependencies) != 0 {
+		t.Errorf("graph after eviction = %v, %v; want empty", c.dependents, c.dependencies)
+	}
+
+	c.DependsOn("b", "c")
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Peek("b"); ok {
+		t.Fatal("b must be expired")
+	}
+	if len(c.dependents) != 0 || len(c.dependencies) != 0 {
+		t.Errorf("graph after expiration = %v, %v; want empty", c.dependents, c.dependencies)
+	}
+}
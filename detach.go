@@ -0,0 +1,48 @@
+package singleflight
+
+import "context"
+
+// WithDetachedContext detaches the context of the function from the caller which triggered
+// the execution, so the execution shared by other callers is not canceled with it:
+// the function receives a context which is only canceled by the group, e.g. by Cancel,
+// WithTimeout or Drain. Values are not inherited either, except the values of keys,
+// the allow-list of context keys copied from the caller, e.g. the keys of a trace ID
+// or of an auth principal, so observability metadata survives the detachment.
+func WithDetachedContext[K comparable, V any](keys ...any) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.detach = true
+		o.detachKeys = make(map[any]struct{}, len(keys))
+		for _, key := range keys {
+			o.detachKeys[key] = struct{}{}
+		}
+	}
+}
+
+// detachedContext is a context without cancellation and deadline, which only resolves
+// the values of the allowed keys from the context of the caller.
+type detachedContext struct {
+	context.Context // background
+	caller          context.Context
+	keys            map[any]struct{}
+}
+
+// Value implements context.Context.
+func (c detachedContext) Value(key any) any {
+	if _, ok := c.keys[key]; ok {
+		return c.caller.Value(key)
+	}
+	if _, ok := key.(chainCtxKey); ok {
+		// keep detecting cycles through the caller
+		return c.caller.Value(key)
+	}
+	return nil
+}
+
+// detachContext returns the context of the function of a call made with ctx,
+// see WithDetachedContext.
+func (g *Group[K, V]) detachContext(ctx context.Context) context.Context {
+	if !g.options().detach {
+		return ctx
+	}
+	return detachedContext{Context: context.Background(), caller: ctx, keys: g.options().detachKeys}
+}
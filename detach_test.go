@@ -0,0 +1,48 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+type traceIDKey struct{}
+
+type principalKey struct{}
+
+func TestDetachedContext(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithDetachedContext[string, int](traceIDKey{}))
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-1")
+	ctx = context.WithValue(ctx, principalKey{}, "alice")
+	ctx, cancel := context.WithCancel(ctx)
+
+	_, _, err := g.Do(ctx, "key", func(ctx context.Context) (int, error) {
+		cancel()
+		if ctx.Err() != nil {
+			t.Error("the function context must not be canceled with the caller")
+		}
+		if v := ctx.Value(traceIDKey{}); v != "trace-1" {
+			t.Errorf("trace ID = %v; want it copied", v)
+		}
+		if v := ctx.Value(principalKey{}); v != nil {
+			t.Errorf("principal = %v; want it not copied", v)
+		}
+		if CallID(ctx) == 0 {
+			t.Error("the call ID must be set")
+		}
+		return 1, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cycles are still detected through detached contexts
+	_, _, err = g.Do(context.Background(), "a", func(ctx context.Context) (int, error) {
+		_, _, err := g.Do(ctx, "a", func(context.Context) (int, error) { return 0, nil })
+		return 0, err
+	})
+	if err == nil {
+		t.Error("Do = nil; want a cycle error")
+	}
+}
@@ -0,0 +1,61 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+)
+
+// Digest is a 128-bit digest of a key, see KeyDigest.
+type Digest [2]uint64
+
+// digestSeeds are the seeds of the two halves of digests. Digests are only
+// meaningful within the process.
+var digestSeeds = [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()}
+
+// KeyDigest returns the 128-bit digest of key.
+func KeyDigest(key string) Digest {
+	return Digest{maphash.String(digestSeeds[0], key), maphash.String(digestSeeds[1], key)}
+}
+
+// digestResult is the result of a DigestGroup call with the key it was computed for.
+type digestResult[V any] struct {
+	key string
+	val V
+}
+
+// DigestGroup is a Group for very large string keys (SQL text, serialized requests):
+// in-flight calls are indexed by a 128-bit digest of the key instead of the key itself,
+// so the map doesn't hold the keys. Only the in-flight call retains its original key,
+// which waiters verify: on a digest collision the waiter executes its function
+// independently instead of receiving the result for another key.
+// The zero value is ready to use.
+type DigestGroup[V any] struct {
+	group Group[Digest, digestResult[V]]
+}
+
+// Do is like Group.Do for the digest of key.
+func (g *DigestGroup[V]) Do(ctx context.Context, key string, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	d := KeyDigest(key)
+	wrapped := func(ctx context.Context) (digestResult[V], error) {
+		v, err := fn(ctx)
+		return digestResult[V]{key: key, val: v}, err
+	}
+
+	res, shared, err := g.group.Do(ctx, d, wrapped)
+	if shared && res.key != key {
+		// digest collision with another key
+		res, _, err = g.group.DoBypass(ctx, d, wrapped, false)
+		return res.val, false, err
+	}
+	return res.val, shared, err
+}
+
+// ForgetUnshared is like Group.ForgetUnshared for the digest of key.
+func (g *DigestGroup[V]) ForgetUnshared(key string) bool {
+	return g.group.ForgetUnshared(KeyDigest(key))
+}
+
+// Stats returns the stats of the group.
+func (g *DigestGroup[V]) Stats() Stats {
+	return g.group.Stats()
+}
@@ -0,0 +1,77 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDigestGroup(t *testing.T) {
+	t.Parallel()
+
+	var g DigestGroup[int]
+	query := "SELECT " + strings.Repeat("column, ", 1000) + "id FROM t"
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := g.Do(context.Background(), query, func(context.Context) (int, error) {
+				<-release
+				return 1, nil
+			}); v != 1 || err != nil {
+				t.Errorf("Do = %v, %v; want 1, nil", v, err)
+			}
+		}()
+	}
+	for g.Stats().Calls < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if st := g.Stats(); st.Executions != 1 {
+		t.Errorf("Executions = %d; want 1", st.Executions)
+	}
+
+	if KeyDigest(query) != KeyDigest(query) || KeyDigest(query) == KeyDigest(query+" ") {
+		t.Error("digests must be deterministic and distinct")
+	}
+}
+
+func TestDigestGroupCollision(t *testing.T) {
+	t.Parallel()
+
+	var g DigestGroup[string]
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	// simulate a collision: a call for another key in flight under the digest of "b"
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.group.Do(context.Background(), KeyDigest("b"), func(context.Context) (digestResult[string], error) {
+			close(started)
+			<-release
+			return digestResult[string]{key: "a", val: "value of a"}, nil
+		})
+	}()
+	<-started
+
+	res := make(chan string)
+	go func() {
+		v, _, _ := g.Do(context.Background(), "b", func(context.Context) (string, error) { return "value of b", nil })
+		res <- v
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if v := <-res; v != "value of b" {
+		t.Errorf("Do on collision = %q; want %q", v, "value of b")
+	}
+	<-done
+}
@@ -0,0 +1,25 @@
+package singleflight
+
+import "context"
+
+// Do2 is like Group.Do but fn takes an argument, so hot paths can pass a static function
+// and its argument instead of allocating a capturing closure for every call:
+// only the caller executing fn wraps it. WithStrictFuncs compares the functions
+// regardless of their argument.
+func Do2[K comparable, A, V any](
+	ctx context.Context, g *Group[K, V], key K, arg A, fn func(context.Context, A) (V, error),
+) (v V, shared bool, err error) { // nolint: revive
+	c, leader, callCtx, ch, err := g.registerDo(ctx, key, fn)
+	if err != nil {
+		return v, false, err
+	}
+	if !leader {
+		g.checkFunc(c, key, fn)
+		res := g.waitResult(ctx, c, key, ch)
+		return res.Val, res.Shared, res.Err
+	}
+
+	g.doCall(callCtx, c, key, func(ctx context.Context) (V, error) { return fn(ctx, arg) })
+	res := c.result()
+	return res.Val, res.Shared, res.Err
+}
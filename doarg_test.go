@@ -0,0 +1,42 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDo2(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	ctx := context.Background()
+	double := func(_ context.Context, n int) (int, error) { return 2 * n, nil }
+
+	if v, shared, err := Do2(ctx, &g, "key", 21, double); v != 42 || shared || err != nil {
+		t.Errorf("Do2 = %v, %v, %v; want 42, false, nil", v, shared, err)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	joined := make(chan int)
+	go func() {
+		v, _, _ := Do2(ctx, &g, "key", 5, double)
+		joined <- v
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-leader
+
+	if v := <-joined; v != 1 {
+		t.Errorf("joined Do2 = %d; want the shared 1", v)
+	}
+}
@@ -0,0 +1,60 @@
+package singleflight
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// DownloadGroup downloads or produces files once: the first caller for a key writes
+// the file into a temporary path, and every caller sharing the result gets a handle
+// on the path. The file is removed once all handles are closed.
+// It suits artifact, layer and model downloads.
+type DownloadGroup[K comparable] struct {
+	dir   string
+	group *ResourceGroup[K, string]
+}
+
+// NewDownloadGroup creates a DownloadGroup writing files into dir,
+// or into the default directory for temporary files if dir is empty.
+func NewDownloadGroup[K comparable](dir string) *DownloadGroup[K] {
+	return &DownloadGroup[K]{
+		dir: dir,
+		group: NewResourceGroup[K](func(path string) {
+			_ = os.Remove(path)
+		}),
+	}
+}
+
+// DoFile returns a handle on the path of the file for key, producing it with fetch,
+// which writes the content to w, unless the file for key is already being produced.
+// The caller must close the handle when done with the file. On error the partial
+// file is removed and no handle is returned.
+func (g *DownloadGroup[K]) DoFile(ctx context.Context, key K, fetch func(ctx context.Context, w io.Writer) error) (h *Handle[string], shared bool, err error) {
+	return g.group.DoShared(ctx, key, func(ctx context.Context) (path string, err error) {
+		f, err := os.CreateTemp(g.dir, "singleflight-*")
+		if err != nil {
+			return "", err
+		}
+		completed := false
+		defer func() {
+			// also on a panic of fetch
+			if !completed {
+				_ = f.Close()
+				_ = os.Remove(f.Name())
+			}
+		}()
+
+		if err = fetch(ctx, f); err == nil {
+			err = f.Sync()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+		completed = true
+		return f.Name(), nil
+	})
+}
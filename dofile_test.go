@@ -0,0 +1,98 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadGroup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	g := NewDownloadGroup[string](dir)
+
+	var fetches atomic.Int32
+	fetch := func(_ context.Context, w io.Writer) error {
+		fetches.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		_, err := io.WriteString(w, "model weights")
+		return err
+	}
+
+	const n = 3
+	handles := make([]*Handle[string], n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, _, err := g.DoFile(context.Background(), "model", fetch)
+			if err != nil {
+				t.Errorf("DoFile error = %v", err)
+				return
+			}
+			handles[i] = h
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d; want 1", got)
+	}
+
+	path := handles[0].Value()
+	if data, err := os.ReadFile(path); string(data) != "model weights" || err != nil {
+		t.Errorf("file = %q, %v", data, err)
+	}
+	for _, h := range handles {
+		if h.Value() != path {
+			t.Errorf("path = %q; want shared %q", h.Value(), path)
+		}
+		_ = h.Close()
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file must be removed after the last handle is closed, stat error = %v", err)
+	}
+}
+
+func TestDownloadGroupError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	g := NewDownloadGroup[string](dir)
+	someErr := errors.New("some error")
+	if _, _, err := g.DoFile(context.Background(), "model", func(_ context.Context, w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return someErr
+	}); !errors.Is(err, someErr) {
+		t.Errorf("DoFile error = %v; want %v", err, someErr)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("partial file must be removed, dir has %d entries", len(entries))
+	}
+}
+
+func TestDownloadGroupPanic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	g := NewDownloadGroup[string](dir)
+	func() {
+		defer func() { _ = recover() }()
+		_, _, _ = g.DoFile(context.Background(), "model", func(_ context.Context, w io.Writer) error {
+			_, _ = io.WriteString(w, "partial")
+			panic("fetch failed")
+		})
+	}()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("partial file must be removed after a panic, dir has %d entries", len(entries))
+	}
+}
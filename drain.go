@@ -0,0 +1,69 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Close stops the group from accepting new calls.
+// Calls which are already in flight run to completion and deliver their results.
+// Subsequent calls to Do and DoChan fail with ErrClosed.
+func (g *Group[K, V]) Close() {
+	g.mu.Lock()
+	g.closed = true
+	g.releasePaused()
+	g.mu.Unlock()
+}
+
+// Terminate closes the group and immediately cancels the contexts of the leaders
+// still in progress, with a cause matching ErrClosed and naming the key, so well-behaved
+// functions stop their work promptly instead of leaking goroutines past shutdown.
+// It returns the keys of the canceled calls and does not wait for them to return.
+func (g *Group[K, V]) Terminate() []K {
+	g.mu.Lock()
+	g.closed = true
+	g.releasePaused()
+	g.mu.Unlock()
+
+	return g.abortRunning(ErrClosed)
+}
+
+// Drain closes the group and waits for all in-flight calls to complete.
+// If ctx is done before that, the contexts of the remaining leaders are canceled
+// with a cause matching ErrDrained and naming the key, and Drain returns their keys together with ctx.Err().
+// Drain does not wait for aborted functions to return.
+func (g *Group[K, V]) Drain(ctx context.Context) (aborted []K, err error) {
+	g.mu.Lock()
+	g.closed = true
+	g.releasePaused()
+	calls := make([]*call[V], 0, len(g.running))
+	for c := range g.running {
+		calls = append(calls, c)
+	}
+	g.mu.Unlock()
+
+	for _, c := range calls {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return g.abortRunning(ErrDrained), ctx.Err()
+		}
+	}
+	return nil, nil
+}
+
+// abortRunning cancels the contexts of all calls in progress
+// with a cause wrapping reason and returns their keys.
+func (g *Group[K, V]) abortRunning(reason error) []K {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]K, 0, len(g.running))
+	for c, key := range g.running {
+		c.cancel(fmt.Errorf("%w: key %v was running for %s", reason, g.keyLabel(key), now.Sub(c.started)))
+		keys = append(keys, key)
+	}
+	return keys
+}
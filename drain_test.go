@@ -0,0 +1,115 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g Group[string, int]
+	g.Close()
+
+	_, _, err := g.Do(ctx, "key", func(context.Context) (int, error) {
+		t.Error("function must not be called after Close")
+		return 0, nil
+	})
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Do error = %v; want %v", err, ErrClosed)
+	}
+
+	res := <-g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		t.Error("function must not be called after Close")
+		return 0, nil
+	})
+	if !errors.Is(res.Err, ErrClosed) {
+		t.Errorf("DoChan error = %v; want %v", res.Err, ErrClosed)
+	}
+}
+
+func TestDrainWaits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g Group[string, int]
+	started := make(chan struct{})
+	ch := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	<-started
+
+	aborted, err := g.Drain(ctx)
+	if err != nil || len(aborted) != 0 {
+		t.Errorf("Drain = %v, %v; want no aborted keys and nil error", aborted, err)
+	}
+
+	select {
+	case res := <-ch:
+		if res.Val != 1 {
+			t.Errorf("DoChan value = %d; want 1", res.Val)
+		}
+	default:
+		t.Error("Drain returned before the call completed")
+	}
+}
+
+func TestDrainAborts(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	started := make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	aborted, err := g.Drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if len(aborted) != 1 || aborted[0] != "key" {
+		t.Errorf("Drain aborted = %v; want [key]", aborted)
+	}
+
+	if res := <-ch; !errors.Is(res.Err, ErrDrained) {
+		t.Errorf("DoChan error = %v; want %v", res.Err, ErrDrained)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	started := make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	})
+	<-started
+
+	if aborted := g.Terminate(); len(aborted) != 1 || aborted[0] != "key" {
+		t.Errorf("Terminate = %v; want [key]", aborted)
+	}
+	res := <-ch
+	if !errors.Is(res.Err, ErrClosed) || !strings.Contains(res.Err.Error(), "key key") {
+		t.Errorf("DoChan error = %v; want %v naming the key", res.Err, ErrClosed)
+	}
+	if _, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Do after Terminate error = %v; want %v", err, ErrClosed)
+	}
+}
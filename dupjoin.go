@@ -0,0 +1,52 @@
+package singleflight
+
+import "context"
+
+// requestIDCtxKey is the context key of the request ID.
+type requestIDCtxKey struct{}
+
+// WithRequestID returns a context carrying the ID of the logical request the caller serves,
+// used to detect duplicate joins, see WithDuplicateJoinDetection.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set with WithRequestID.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDCtxKey{}).(string)
+	return id, ok
+}
+
+// WithDuplicateJoinDetection is a debugging option calling report when a request,
+// identified by the ID of WithRequestID, takes part more than once in the same in-flight
+// call of key, usually a sign of redundant call paths which should be fixed upstream.
+// Callers without request ID are not tracked.
+func WithDuplicateJoinDetection[K comparable, V any](report func(key K, label any, requestID string)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onDuplicateJoin = report
+	}
+}
+
+// trackRequest records the request of a caller with ctx taking part in the call c for key,
+// and returns the function reporting a duplicate join, if any, to call once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) trackRequest(ctx context.Context, c *call[V], key K) func() {
+	report := g.options().onDuplicateJoin
+	if report == nil {
+		return func() {}
+	}
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return func() {}
+	}
+
+	if _, dup := c.requests[id]; dup {
+		label := g.keyLabel(key)
+		return func() { report(key, label, id) }
+	}
+	if c.requests == nil {
+		c.requests = make(map[string]struct{})
+	}
+	c.requests[id] = struct{}{}
+	return func() {}
+}
@@ -0,0 +1,45 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDuplicateJoinDetection(t *testing.T) {
+	t.Parallel()
+
+	var reported []string
+	g := NewGroup(WithDuplicateJoinDetection[string, int](func(key string, _ any, id string) {
+		reported = append(reported, key+"/"+id)
+	}))
+	req1 := WithRequestID(context.Background(), "req-1")
+	req2 := WithRequestID(context.Background(), "req-2")
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(req1, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	fn := func(context.Context) (int, error) { return 2, nil }
+	chs := []<-chan Result[int]{
+		g.DoChan(req2, "key", fn),
+		g.DoChan(context.Background(), "key", fn),
+		g.DoChan(context.Background(), "key", fn),
+		g.DoChan(req1, "key", fn),
+	}
+	close(release)
+	<-leader
+	for _, ch := range chs {
+		<-ch
+	}
+
+	if len(reported) != 1 || reported[0] != "key/req-1" {
+		t.Errorf("reported = %v; want key/req-1", reported)
+	}
+	if id, ok := RequestIDFromContext(req2); !ok || id != "req-2" {
+		t.Errorf("RequestIDFromContext = %q, %v", id, ok)
+	}
+}
@@ -0,0 +1,16 @@
+package singleflight
+
+// SetEnabled turns deduplication on or off at runtime. While it is off, every call
+// executes its own function, like DoBypass, and results are not shared; the rest of
+// the behavior (options, hooks, stats) is preserved. Operators can use it to rule
+// singleflight in or out during an incident without a deploy.
+// Calls in flight when deduplication is turned off still complete for their waiters.
+// Deduplication is on by default.
+func (g *Group[K, V]) SetEnabled(enabled bool) {
+	g.disabled.Store(!enabled)
+}
+
+// Enabled reports whether deduplication is on, see SetEnabled.
+func (g *Group[K, V]) Enabled() bool {
+	return !g.disabled.Load()
+}
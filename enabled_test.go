@@ -0,0 +1,39 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetEnabled(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	if !g.Enabled() {
+		t.Fatal("deduplication must be on by default")
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	first := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	g.SetEnabled(false)
+	if v, shared, _ := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil }); v != 2 || shared {
+		t.Errorf("Do while disabled = %v, %v; want own result 2, false", v, shared)
+	}
+
+	g.SetEnabled(true)
+	second := g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 3, nil })
+	close(release)
+	if res := <-first; res.Val != 1 {
+		t.Errorf("first = %+v; want 1", res)
+	}
+	if res := <-second; res.Val != 1 || !res.Shared {
+		t.Errorf("second = %+v; want the shared 1 once enabled again", res)
+	}
+}
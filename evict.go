@@ -0,0 +1,51 @@
+package singleflight
+
+// EvictReason tells why a value left a Cache.
+type EvictReason int
+
+const (
+	// EvictSize means the value was the least recently used one of a full cache.
+	EvictSize EvictReason = iota
+	// EvictExpired means the TTL of the value elapsed.
+	EvictExpired
+	// EvictReplaced means a new value was stored for the key.
+	EvictReplaced
+	// EvictForgotten means the value was removed with Forget.
+	EvictForgotten
+)
+
+// String returns the name of the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case EvictSize:
+		return "size"
+	case EvictExpired:
+		return "expired"
+	case EvictReplaced:
+		return "replaced"
+	case EvictForgotten:
+		return "forgotten"
+	default:
+		return "unknown"
+	}
+}
+
+// OnEvict registers fn to be called whenever a value leaves the cache, so resources held
+// by cached values can be released and eviction patterns observed.
+// fn is called without holding the cache lock, after the value was removed.
+// Expired values are reported when they are noticed by Get or Peek, not when their TTL elapses.
+func OnEvict[K comparable, V any](fn func(key K, val V, reason EvictReason)) CacheOption[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvict = fn
+	}
+}
+
+// evicted reports the removed entries to the eviction callback.
+func (c *Cache[K, V]) evicted(reason EvictReason, entries ...lruEntry[K, cacheEntry[V]]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, ent := range entries {
+		c.onEvict(ent.key, ent.val.val, reason)
+	}
+}
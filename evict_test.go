@@ -0,0 +1,57 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheOnEvict(t *testing.T) {
+	t.Parallel()
+
+	type eviction struct {
+		key    string
+		val    int
+		reason EvictReason
+	}
+	var (
+		mu        sync.Mutex
+		evictions []eviction
+	)
+	c := NewCache(50*time.Millisecond,
+		WithCacheSize[string, int](2),
+		OnEvict(func(key string, val int, reason EvictReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			evictions = append(evictions, eviction{key, val, reason})
+		}))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("a", 10) // replaced
+	c.Set("c", 3)  // evicts b
+	c.Forget("c")
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := c.Get(context.Background(), "a", func(context.Context) (int, error) { return 11, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []eviction{
+		{"a", 1, EvictReplaced},
+		{"b", 2, EvictSize},
+		{"c", 3, EvictForgotten},
+		{"a", 10, EvictExpired},
+	}
+	if len(evictions) != len(want) {
+		t.Fatalf("evictions = %v; want %v", evictions, want)
+	}
+	for i := range want {
+		if evictions[i] != want[i] {
+			t.Errorf("eviction %d = %v; want %v", i, evictions[i], want[i])
+		}
+	}
+}
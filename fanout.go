@@ -0,0 +1,55 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyWaiters is matched by the errors returned to the callers which would exceed
+// the maximum number of channels receiving the result of a call, see WithMaxSubscribers.
+var ErrTooManyWaiters = errors.New("singleflight: too many waiters")
+
+// WithMaxSubscribers limits to n the channels receiving the result of an in-flight call,
+// those of DoChan, DoChanCancel, and of Do with WithFIFOWakeup, bounding the memory and
+// the fan-out latency of pathological pile-ups. Beyond the limit, callers get an error
+// matching ErrTooManyWaiters or, if redirect is true, start a fresh call for the key,
+// which the next callers join instead of the full one.
+func WithMaxSubscribers[K comparable, V any](n int, redirect bool) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxSubscribers = n
+		o.redirectSubscribers = redirect
+	}
+}
+
+// subscribersFull reports whether the in-flight call c can't accept the channel ch.
+// Must be called with g.mu held.
+func (g *Group[K, V]) subscribersFull(c *call[V], ch chan<- Result[V]) bool {
+	return ch != nil && g.options().maxSubscribers > 0 && len(c.chans) >= g.options().maxSubscribers
+}
+
+// mustRedirect reports whether a caller with ctx must start a fresh call instead of joining
+// the in-flight call c with the channel ch, see WithMaxSubscribers.
+// Must be called with g.mu held.
+func (g *Group[K, V]) mustRedirect(ctx context.Context, c *call[V], ch chan<- Result[V]) bool {
+	if !g.options().redirectSubscribers || !g.subscribersFull(c, ch) {
+		return false
+	}
+	_, canShed := g.shedVictim(ctx, c)
+	return !canShed
+}
+
+// checkSubscribers makes room for the channel ch of a caller with ctx joining the in-flight
+// call c for key, shedding a waiter with a lower priority if needed, see WithPriorityShedding.
+// It returns an error if there is no room.
+// Must be called with g.mu held.
+func (g *Group[K, V]) checkSubscribers(ctx context.Context, c *call[V], key K, ch chan<- Result[V]) error {
+	if !g.subscribersFull(c, ch) {
+		return nil
+	}
+	if victim, ok := g.shedVictim(ctx, c); ok {
+		g.shed(c, key, victim)
+		return nil
+	}
+	return fmt.Errorf("%w: %d channels wait for key %v", ErrTooManyWaiters, len(c.chans), g.keyLabel(key))
+}
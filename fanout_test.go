@@ -0,0 +1,42 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMaxSubscribers(t *testing.T) {
+	t.Parallel()
+
+	for _, redirect := range []bool{false, true} {
+		g := NewGroup(WithMaxSubscribers[string, int](2, redirect))
+		ctx := context.Background()
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		leader := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		<-started
+		joined := g.DoChan(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+		extra := g.DoChan(ctx, "key", func(context.Context) (int, error) { return 3, nil })
+
+		res := <-extra
+		switch {
+		case redirect && (res.Val != 3 || res.Err != nil):
+			t.Errorf("redirected result = %+v; want a fresh execution", res)
+		case !redirect && !errors.Is(res.Err, ErrTooManyWaiters):
+			t.Errorf("extra result = %+v; want ErrTooManyWaiters", res)
+		}
+		close(release)
+		if res := <-leader; res.Val != 1 {
+			t.Errorf("leader result = %+v", res)
+		}
+		if res := <-joined; res.Val != 1 {
+			t.Errorf("joined result = %+v", res)
+		}
+	}
+}
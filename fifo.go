@@ -0,0 +1,12 @@
+package singleflight
+
+// WithFIFOWakeup releases the callers of Do waiting for a call in the order they joined it,
+// like the channels of DoChan, so earlier callers aren't starved behind later ones when
+// the result triggers follow-on contention. Each waiting caller then uses a channel
+// instead of sharing a sync.WaitGroup, which costs an allocation per caller.
+// The order is the order of the wake-ups; the scheduler still decides when each caller runs.
+func WithFIFOWakeup[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.fifo = true
+	}
+}
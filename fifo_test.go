@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFIFOWakeup(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+	g := NewGroup(WithFIFOWakeup[string, int]())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	const n = 5
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, shared, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil }); v != 1 || !shared || err != nil {
+				t.Errorf("Do = %v, %v, %v; want shared 1", v, shared, err)
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		}()
+		// join one at a time
+		for g.Stats().Shared < uint64(i+1) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	<-leader
+	wg.Wait()
+
+	if len(order) != n {
+		t.Errorf("released callers = %v; want %d", order, n)
+	}
+}
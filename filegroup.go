@@ -0,0 +1,129 @@
+package singleflight
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrFileLockUnsupported is returned by FileGroup on platforms without advisory file locks.
+var ErrFileLockUnsupported = errors.New("singleflight: file locks are not supported on this platform")
+
+// fileLockPollInterval is the interval between attempts to take a busy file lock.
+const fileLockPollInterval = 10 * time.Millisecond
+
+// FileGroup deduplicates executions across processes on one host using advisory
+// file locks (flock) and result files in a directory. It suits CLIs and cron jobs
+// where concurrent invocations of the same expensive step (a token refresh,
+// an artifact download) should execute once per machine.
+//
+// Only successful results are shared through files: if the function fails,
+// the next process waiting for the key executes it again.
+type FileGroup[V any] struct {
+	dir    string
+	codec  Codec[V]
+	maxAge time.Duration
+	local  Group[string, V]
+}
+
+// NewFileGroup creates a FileGroup keeping its lock and result files in dir.
+// A result file is reused if it was written while the caller was waiting for
+// the lock, or if it is not older than maxAge.
+func NewFileGroup[V any](dir string, codec Codec[V], maxAge time.Duration) *FileGroup[V] {
+	return &FileGroup[V]{dir: dir, codec: codec, maxAge: maxAge}
+}
+
+// Do executes fn for key unless another process on the host executes it concurrently
+// or recently did, in which case the result is read from the result file.
+// Calls within the process are deduplicated by a Group first.
+// shared reports whether the result was produced by another caller or process.
+func (g *FileGroup[V]) Do(ctx context.Context, key string, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	var fromFile bool
+	v, shared, err = g.local.Do(ctx, key, func(ctx context.Context) (V, error) {
+		var err error
+		v, fromFile, err = g.do(ctx, key, fn)
+		return v, err
+	})
+	return v, shared || fromFile, err
+}
+
+func (g *FileGroup[V]) do(ctx context.Context, key string, fn doFunc[V]) (v V, fromFile bool, err error) {
+	if err = os.MkdirAll(g.dir, 0o700); err != nil {
+		return v, false, fmt.Errorf("singleflight: create lock directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	base := filepath.Join(g.dir, hex.EncodeToString(sum[:16]))
+	resultPath := base + ".result"
+
+	waitStarted := time.Now()
+	unlock, err := lockFile(ctx, base+".lock")
+	if err != nil {
+		return v, false, err
+	}
+	defer unlock()
+
+	if v, ok := g.readResult(resultPath, waitStarted); ok {
+		return v, true, nil
+	}
+
+	if v, err = fn(ctx); err != nil {
+		return v, false, err
+	}
+	if err = g.writeResult(resultPath, v); err != nil {
+		return v, false, err
+	}
+	return v, false, nil
+}
+
+// readResult returns the value of the result file if it is fresh enough.
+func (g *FileGroup[V]) readResult(path string, waitStarted time.Time) (v V, ok bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return v, false
+	}
+	if info.ModTime().Before(waitStarted) && (g.maxAge <= 0 || time.Since(info.ModTime()) > g.maxAge) {
+		return v, false
+	}
+
+	data, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return v, false
+	}
+	if v, err = g.codec.Unmarshal(data); err != nil {
+		return v, false
+	}
+	return v, true
+}
+
+// writeResult atomically replaces the result file with v.
+func (g *FileGroup[V]) writeResult(path string, v V) error {
+	data, err := g.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("singleflight: encode result: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(g.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("singleflight: write result: %w", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("singleflight: write result: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("singleflight: write result: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("singleflight: write result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+//go:build unix
+
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Separate FileGroups on the same directory behave like separate processes.
+	const n = 5
+	var (
+		calls atomic.Int32
+		wg    sync.WaitGroup
+	)
+	results := make([]string, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewFileGroup[string](dir, JSONCodec[string]{}, 0)
+			v, _, err := g.Do(ctx, "token", func(context.Context) (string, error) {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return "secret", nil
+			})
+			if err != nil {
+				t.Errorf("Do error = %v", err)
+			}
+			results[i] = v
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("function called %d times; want 1", got)
+	}
+	for i, v := range results {
+		if v != "secret" {
+			t.Errorf("result %d = %q; want %q", i, v, "secret")
+		}
+	}
+}
+
+func TestFileGroupMaxAge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	g := NewFileGroup[int](dir, JSONCodec[int]{}, time.Minute)
+
+	if v, shared, err := g.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || shared || err != nil {
+		t.Fatalf("Do = %v, %v, %v; want 1, false, nil", v, shared, err)
+	}
+	v, shared, err := g.Do(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+	if v != 1 || !shared || err != nil {
+		t.Errorf("Do = %v, %v, %v; want the stored 1, true, nil", v, shared, err)
+	}
+
+	// Errors are not stored.
+	someErr := errors.New("some error")
+	if _, _, err = g.Do(ctx, "other", func(context.Context) (int, error) { return 0, someErr }); !errors.Is(err, someErr) {
+		t.Errorf("Do error = %v; want %v", err, someErr)
+	}
+	if v, _, _ = g.Do(ctx, "other", func(context.Context) (int, error) { return 3, nil }); v != 3 {
+		t.Errorf("Do after error = %v; want 3", v)
+	}
+}
+
+func TestFileGroupLockContext(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	unlock, err := lockFile(context.Background(), dir+"/x.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err = lockFile(ctx, dir+"/x.lock"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("lockFile error = %v; want %v", err, context.DeadlineExceeded)
+	}
+}
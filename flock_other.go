@@ -0,0 +1,15 @@
+//go:build !unix
+
+package singleflight
+
+import "context"
+
+// lockFile is not supported on this platform.
+func lockFile(context.Context, string) (unlock func(), err error) {
+	return nil, ErrFileLockUnsupported
+}
+
+// tryLockFile is not supported on this platform.
+func tryLockFile(string) (unlock func(), err error) {
+	return nil, ErrFileLockUnsupported
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFile takes an exclusive advisory lock on the file at path, creating it if necessary.
+// It polls until the lock is taken or ctx is done.
+func lockFile(ctx context.Context, path string) (unlock func(), err error) {
+	for {
+		unlock, err = tryLockFile(path)
+		if err != nil || unlock != nil {
+			return unlock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fileLockPollInterval):
+		}
+	}
+}
+
+// tryLockFile takes an exclusive advisory lock on the file at path, creating it if necessary.
+// It returns a nil unlock function if the lock is held by someone else.
+func tryLockFile(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600) // nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("singleflight: open lock file: %w", err)
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) || errors.Is(err, syscall.EINTR) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("singleflight: lock file: %w", err)
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,23 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// DoFresh is like Do but only joins an in-flight call which started at or after notBefore,
+// for read-your-writes flows: pass the time of the last mutation. If the in-flight call
+// started earlier, DoFresh starts a new execution which replaces it as in DoBypass,
+// so callers arriving later share the fresh execution. A zero notBefore behaves like Do.
+func (g *Group[K, V]) DoFresh(ctx context.Context, key K, notBefore time.Time, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	g.mu.Lock()
+	c, ok := g.m[key]
+	stale := ok && c.started.Before(notBefore)
+	g.mu.Unlock()
+
+	if stale {
+		return g.DoBypass(ctx, key, fn, true)
+	}
+	// a call registered after the check started after notBefore, unless notBefore is in the future
+	return g.Do(ctx, key, fn)
+}
@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDoFresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	wrote := time.Now()
+	v, _, err := g.DoFresh(ctx, "key", wrote, func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || err != nil {
+		t.Errorf("DoFresh = %v, %v; want fresh 2, nil", v, err)
+	}
+}
+
+func TestCacheGetFresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](time.Minute)
+	c.Set("key", 1)
+
+	if v, _ := c.GetFresh(ctx, "key", time.Now().Add(-time.Minute), func(context.Context) (int, error) { return 2, nil }); v != 1 {
+		t.Errorf("GetFresh with an old bound = %d; want cached 1", v)
+	}
+	if v, _ := c.GetFresh(ctx, "key", time.Now(), func(context.Context) (int, error) { return 3, nil }); v != 3 {
+		t.Errorf("GetFresh after a write = %d; want fresh 3", v)
+	}
+	if v, _ := c.Get(ctx, "key", func(context.Context) (int, error) { return 4, nil }); v != 3 {
+		t.Errorf("Get = %d; want the refreshed value 3", v)
+	}
+}
@@ -0,0 +1,78 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"time"
+)
+
+// FSOption configures a FS created by NewFS.
+type FSOption func(*FS)
+
+// FS is a fs.FS collapsing concurrent ReadFile and Stat calls for the same path
+// into one call of the wrapped file system, for template, config or asset loading
+// paths hit by many goroutines at once. Read contents can also be cached, see WithFSCache.
+// Open is passed through.
+type FS struct {
+	fsys  fs.FS
+	reads Group[string, []byte]
+	stats Group[string, fs.FileInfo]
+	cache *Cache[string, []byte]
+}
+
+var (
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// NewFS wraps fsys.
+func NewFS(fsys fs.FS, opts ...FSOption) *FS {
+	f := &FS{fsys: fsys}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// WithFSCache caches file contents read by ReadFile for ttl, for at most size files
+// (0 means unbounded). Errors are not cached.
+func WithFSCache(ttl time.Duration, size int) FSOption {
+	return func(f *FS) {
+		f.cache = NewCache(ttl, WithCacheSize[string, []byte](size))
+	}
+}
+
+// Open implements fs.FS.
+func (f *FS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+// ReadFile implements fs.ReadFileFS. Every caller receives its own copy of the contents.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	read := func(context.Context) ([]byte, error) {
+		return fs.ReadFile(f.fsys, name)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if f.cache != nil {
+		data, err = f.cache.Get(context.Background(), name, read)
+	} else {
+		data, _, err = f.reads.Do(context.Background(), name, read)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(data), nil
+}
+
+// Stat implements fs.StatFS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	info, _, err := f.stats.Do(context.Background(), name, func(context.Context) (fs.FileInfo, error) {
+		return fs.Stat(f.fsys, name)
+	})
+	return info, err
+}
@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// countingFS counts the opened files of a slow file system.
+type countingFS struct {
+	files fstest.MapFS
+	opens atomic.Int32
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.opens.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	return c.files.Open(name)
+}
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+
+	base := &countingFS{files: fstest.MapFS{"conf.txt": {Data: []byte("config")}}}
+	fsys := NewFS(base, WithFSCache(time.Minute, 0))
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := fsys.ReadFile("conf.txt")
+			if string(data) != "config" || err != nil {
+				t.Errorf("ReadFile = %q, %v; want config, nil", data, err)
+				return
+			}
+			data[0] = 'X' // callers own their copy
+		}()
+	}
+	wg.Wait()
+	if got := base.opens.Load(); got != 1 {
+		t.Errorf("file opened %d times; want 1", got)
+	}
+
+	if info, err := fsys.Stat("conf.txt"); err != nil || info.Size() != 6 {
+		t.Errorf("Stat = %v, %v; want size 6", info, err)
+	}
+	if _, err := fsys.ReadFile("missing.txt"); err == nil {
+		t.Error("ReadFile of a missing file must fail")
+	}
+	if err := fstest.TestFS(NewFS(base.files), "conf.txt"); err != nil {
+		t.Error(err)
+	}
+}
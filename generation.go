@@ -0,0 +1,48 @@
+package singleflight
+
+// Generation returns the current generation of the group: every execution gets
+// a generation greater than those of the executions started before it.
+// An invalidator takes the generation right after its write, and passes it to
+// ForgetIfGeneration to discard only the calls which may have read the old data.
+func (g *Group[K, V]) Generation() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.generation
+}
+
+// KeyGeneration returns the generation of the in-flight call for key, if any.
+func (g *Group[K, V]) KeyGeneration(key K) (uint64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.m[key]
+	if !ok {
+		return 0, false
+	}
+	return c.gen, true
+}
+
+// ForgetIfGeneration forgets the in-flight call for key if its generation is at most gen,
+// so callers arriving later start a new execution, and reports whether it did.
+// Unlike a plain forget, it cannot discard a call started after the invalidation
+// it was called for. Callers already waiting for a forgotten call still receive its result.
+func (g *Group[K, V]) ForgetIfGeneration(key K, gen uint64) bool {
+	return g.ForgetWhere(key, func(ci CallInfo) bool { return ci.Generation <= gen })
+}
+
+// ForgetWhere forgets the in-flight call for key if pred returns true for it,
+// and reports whether it did, e.g. to forget a call only if it started before some time.
+// pred is called with the group locked, so the call cannot change meanwhile;
+// it must be fast and must not use the group.
+func (g *Group[K, V]) ForgetWhere(key K, pred func(CallInfo) bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.m[key]
+	if !ok || !pred(g.callInfo(c, key)) {
+		return false
+	}
+	delete(g.m, key)
+	return true
+}
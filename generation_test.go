@@ -0,0 +1,73 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForgetIfGeneration(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		started <- struct{}{}
+		<-release
+		return 1, nil
+	}
+
+	old := g.DoChan(context.Background(), "key", fn)
+	<-started
+	gen := g.Generation() // taken after a write, the call may have read the old data
+	if keyGen, ok := g.KeyGeneration("key"); !ok || keyGen != gen {
+		t.Errorf("KeyGeneration = %d, %v; want %d, true", keyGen, ok, gen)
+	}
+
+	if !g.ForgetIfGeneration("key", gen) {
+		t.Error("call started before the token must be forgotten")
+	}
+	fresh := g.DoChan(context.Background(), "key", fn)
+	<-started
+	if g.ForgetIfGeneration("key", gen) {
+		t.Error("call started after the token must not be forgotten")
+	}
+	if calls := g.Calls(); len(calls) != 2 || calls[1].Generation != gen+1 {
+		t.Errorf("Calls = %+v; want the fresh call with generation %d", calls, gen+1)
+	}
+
+	close(release)
+	<-old
+	<-fresh
+	if _, ok := g.KeyGeneration("key"); ok {
+		t.Error("KeyGeneration of a completed key must not be found")
+	}
+}
+
+func TestForgetWhere(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	defer func() {
+		close(release)
+		<-ch
+	}()
+
+	if g.ForgetWhere("key", func(ci CallInfo) bool { return ci.Dups > 0 }) {
+		t.Error("call without duplicates must not be forgotten")
+	}
+	if !g.ForgetWhere("key", func(ci CallInfo) bool { return ci.Key == "key" && !ci.Forgotten }) {
+		t.Error("matching call must be forgotten")
+	}
+	if g.ForgetWhere("key", func(CallInfo) bool { return true }) {
+		t.Error("forgotten call must not be found")
+	}
+}
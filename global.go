@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+)
+
+// globals is the process-wide registry of groups, see Global.
+var globals struct {
+	mu     sync.Mutex
+	groups map[string]any // *Group[K, V] by namespace
+}
+
+// Global returns the process-wide group of namespace, creating it with opts on first use,
+// so packages which build their own group for the same resource still share executions:
+// calls with the same namespace and key are deduplicated across the whole process.
+// The group is named namespace, see WithName.
+//
+// Only the call creating the group may pass options: since options can't be compared,
+// Global panics if a later call for the namespace passes any, rather than silently
+// ignoring them. Use UpdateOptions to retune the group. Global also panics if the
+// namespace is used with different key or value types.
+//
+// The group is shared by unrelated packages, so it must not be closed, drained or
+// terminated: that would make the calls of every package fail with ErrClosed.
+func Global[K comparable, V any](namespace string, opts ...Option[K, V]) *Group[K, V] {
+	globals.mu.Lock()
+	defer globals.mu.Unlock()
+
+	if existing, ok := globals.groups[namespace]; ok {
+		g, ok := existing.(*Group[K, V])
+		if !ok {
+			panic(fmt.Sprintf("singleflight: namespace %q is used by a %T", namespace, existing))
+		}
+		if len(opts) > 0 {
+			panic(fmt.Sprintf("singleflight: options for the existing global namespace %q", namespace))
+		}
+		return g
+	}
+
+	g := NewGroup(append([]Option[K, V]{WithName[K, V](namespace)}, opts...)...)
+	if globals.groups == nil {
+		globals.groups = make(map[string]any)
+	}
+	globals.groups[namespace] = g
+	return g
+}
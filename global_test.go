@@ -0,0 +1,44 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGlobal(t *testing.T) {
+	t.Parallel()
+
+	a := Global[string, int]("test.global")
+	b := Global[string, int]("test.global")
+	if a != b {
+		t.Fatal("Global must return the same group for a namespace")
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := a.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	joined := b.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	close(release)
+	<-leader
+	if res := <-joined; res.Val != 1 || !res.Shared {
+		t.Errorf("result = %+v; want the shared 1", res)
+	}
+
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Global %s must panic", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("with other types", func() { Global[string, string]("test.global") })
+	mustPanic("with later options", func() { Global("test.global", WithTimeout[string, int](time.Second)) })
+}
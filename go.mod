@@ -1,3 +1,3 @@
 module github.com/n-r-w/singleflight/v2
 
-go 1.19
+go 1.24
@@ -0,0 +1,195 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownNode is returned by Graph.Do for keys which were not added to the graph.
+var ErrUnknownNode = errors.New("singleflight: unknown graph node")
+
+// GraphFunc computes the value of a graph node from the values of its dependencies.
+type GraphFunc[K comparable, V any] func(ctx context.Context, deps map[K]V) (V, error)
+
+// DependencyError is returned by Graph.Do when a dependency of a node failed.
+type DependencyError[K comparable] struct {
+	Key   K   // the failed dependency
+	Label any // Key redacted if the group has a key redactor, see WithKeyRedactor
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *DependencyError[K]) Error() string {
+	return fmt.Sprintf("singleflight: dependency %v failed: %v", e.Label, e.Err)
+}
+
+// Unwrap returns the error of the failed dependency.
+func (e *DependencyError[K]) Unwrap() error {
+	return e.Err
+}
+
+// Graph is a lightweight keyed task graph: each key declares the keys it depends on.
+// Computing a key first computes its dependencies, independent ones concurrently.
+// Every node is executed through the underlying Group, so a node shared by several
+// dependents, or requested by concurrent callers, is computed once.
+type Graph[K comparable, V any] struct {
+	group *Group[K, V]
+
+	mu    sync.RWMutex
+	nodes map[K]graphNode[K, V]
+}
+
+type graphNode[K comparable, V any] struct {
+	deps []K
+	fn   GraphFunc[K, V]
+}
+
+// NewGraph creates a graph executing its nodes through g.
+// If g is nil, a new Group is used.
+func NewGraph[K comparable, V any](g *Group[K, V]) *Graph[K, V] {
+	if g == nil {
+		g = &Group[K, V]{}
+	}
+	return &Graph[K, V]{
+		group: g,
+		nodes: make(map[K]graphNode[K, V]),
+	}
+}
+
+// Add declares that key is computed by fn from the values of deps.
+// Adding an existing key replaces its declaration.
+func (gr *Graph[K, V]) Add(key K, deps []K, fn GraphFunc[K, V]) {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	gr.nodes[key] = graphNode[K, V]{deps: append([]K(nil), deps...), fn: fn}
+}
+
+// Do computes the value of key after computing all of its dependencies.
+// It returns ErrUnknownNode if key or one of its transitive dependencies is not declared,
+// a CycleError if the dependencies form a cycle, and a DependencyError if a dependency failed.
+func (gr *Graph[K, V]) Do(ctx context.Context, key K) (V, error) {
+	if err := gr.validate(key); err != nil {
+		var zero V
+		return zero, err
+	}
+	return gr.run(ctx, key)
+}
+
+// validate checks that all transitive dependencies of key are declared and acyclic.
+func (gr *Graph[K, V]) validate(key K) error {
+	gr.mu.RLock()
+	defer gr.mu.RUnlock()
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[K]int)
+	var path []K
+
+	var visit func(key K) error
+	visit = func(key K) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, k := range path {
+				if k == key {
+					start = i
+					break
+				}
+			}
+			labels := make([]any, 0, len(path)-start+1)
+			for _, k := range append(path[start:], key) {
+				labels = append(labels, gr.group.keyLabel(k))
+			}
+			return &CycleError{Path: labels}
+		}
+
+		node, ok := gr.nodes[key]
+		if !ok {
+			return fmt.Errorf("%w: %v", ErrUnknownNode, gr.group.keyLabel(key))
+		}
+
+		state[key] = visiting
+		path = append(path, key)
+		for _, dep := range node.deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		return nil
+	}
+
+	return visit(key)
+}
+
+// run computes key through the group without validating the graph.
+func (gr *Graph[K, V]) run(ctx context.Context, key K) (V, error) {
+	v, _, err := gr.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		gr.mu.RLock()
+		node, ok := gr.nodes[key]
+		gr.mu.RUnlock()
+		if !ok {
+			var zero V
+			return zero, fmt.Errorf("%w: %v", ErrUnknownNode, gr.group.keyLabel(key))
+		}
+
+		deps, err := gr.runDeps(ctx, node.deps)
+		if err != nil {
+			var zero V
+			return zero, err
+		}
+		return node.fn(ctx, deps)
+	})
+	return v, err
+}
+
+// runDeps computes deps concurrently. The first failure cancels the remaining dependencies.
+func (gr *Graph[K, V]) runDeps(ctx context.Context, deps []K) (map[K]V, error) {
+	values := make(map[K]V, len(deps))
+	if len(deps) == 0 {
+		return values, nil
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for _, dep := range deps {
+		dep := dep
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := gr.run(ctx, dep)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = &DependencyError[K]{Key: dep, Label: gr.group.keyLabel(dep), Err: err}
+					cancel(firstErr)
+				}
+				return
+			}
+			values[dep] = v
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return values, nil
+}
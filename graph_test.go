@@ -0,0 +1,89 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGraphDo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	gr := NewGraph[string, int](nil)
+
+	var leafCalls atomic.Int32
+	gr.Add("leaf", nil, func(context.Context, map[string]int) (int, error) {
+		leafCalls.Add(1)
+		return 1, nil
+	})
+	gr.Add("b", []string{"leaf"}, func(_ context.Context, deps map[string]int) (int, error) {
+		return deps["leaf"] + 10, nil
+	})
+	gr.Add("c", []string{"leaf"}, func(_ context.Context, deps map[string]int) (int, error) {
+		return deps["leaf"] + 100, nil
+	})
+	gr.Add("a", []string{"b", "c"}, func(_ context.Context, deps map[string]int) (int, error) {
+		return deps["b"] + deps["c"], nil
+	})
+
+	v, err := gr.Do(ctx, "a")
+	if err != nil {
+		t.Fatalf("Do error = %v", err)
+	}
+	if v != 112 {
+		t.Errorf("Do = %d; want 112", v)
+	}
+	if got := leafCalls.Load(); got < 1 || got > 2 {
+		t.Errorf("leaf calls = %d; want 1 or 2", got)
+	}
+}
+
+func TestGraphDependencyError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	gr := NewGraph[string, int](nil)
+	someErr := errors.New("some error")
+
+	gr.Add("b", nil, func(context.Context, map[string]int) (int, error) {
+		return 0, someErr
+	})
+	gr.Add("a", []string{"b"}, func(context.Context, map[string]int) (int, error) {
+		t.Error("a must not be computed when b fails")
+		return 0, nil
+	})
+
+	_, err := gr.Do(ctx, "a")
+	if !errors.Is(err, someErr) {
+		t.Errorf("Do error = %v; want %v", err, someErr)
+	}
+	var depErr *DependencyError[string]
+	if !errors.As(err, &depErr) || depErr.Key != "b" {
+		t.Errorf("Do error = %v; want dependency error for b", err)
+	}
+}
+
+func TestGraphValidate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	gr := NewGraph[string, int](nil)
+	fn := func(context.Context, map[string]int) (int, error) { return 0, nil }
+
+	gr.Add("a", []string{"b"}, fn)
+	if _, err := gr.Do(ctx, "a"); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("Do error = %v; want %v", err, ErrUnknownNode)
+	}
+
+	gr.Add("b", []string{"c"}, fn)
+	gr.Add("c", []string{"a"}, fn)
+	_, err := gr.Do(ctx, "a")
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Do error = %v; want %v", err, ErrCycle)
+	}
+	if got, want := err.Error(), "singleflight: call cycle detected: a -> b -> c -> a"; got != want {
+		t.Errorf("error text = %q; want %q", got, want)
+	}
+}
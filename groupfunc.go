@@ -0,0 +1,38 @@
+package singleflight
+
+import "context"
+
+// GroupFunc is a Group whose function is bound at construction: Do takes only the key,
+// so call sites can't pass different functions for the same key, and don't need
+// to build a closure per call.
+type GroupFunc[K comparable, V any] struct {
+	group *Group[K, V]
+	fn    LoadFunc[K, V]
+}
+
+// NewGroupFunc creates a GroupFunc executing fn, with a group configured with opts.
+func NewGroupFunc[K comparable, V any](fn LoadFunc[K, V], opts ...Option[K, V]) *GroupFunc[K, V] {
+	return &GroupFunc[K, V]{group: NewGroup(opts...), fn: fn}
+}
+
+// Do is Group.Do with the bound function.
+func (g *GroupFunc[K, V]) Do(ctx context.Context, key K) (v V, shared bool, err error) { // nolint: revive
+	return g.group.Do(ctx, key, g.bind(key))
+}
+
+// DoChan is Group.DoChan with the bound function.
+func (g *GroupFunc[K, V]) DoChan(ctx context.Context, key K) <-chan Result[V] {
+	return g.group.DoChan(ctx, key, g.bind(key))
+}
+
+// Group returns the underlying group, e.g. to forget keys or read its stats.
+func (g *GroupFunc[K, V]) Group() *Group[K, V] {
+	return g.group
+}
+
+// bind returns the bound function applied to key.
+func (g *GroupFunc[K, V]) bind(key K) doFunc[V] {
+	return func(ctx context.Context) (V, error) {
+		return g.fn(ctx, key)
+	}
+}
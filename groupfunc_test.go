@@ -0,0 +1,45 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupFunc(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	g := NewGroupFunc(func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return len(key), nil
+	})
+
+	ch := g.DoChan(context.Background(), "abc")
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := g.Do(context.Background(), "abc"); v != 3 || err != nil {
+				t.Errorf("Do = %v, %v; want 3, nil", v, err)
+			}
+		}()
+	}
+	for g.Group().Stats().Calls < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if res := <-ch; res.Val != 3 || !res.Shared {
+		t.Errorf("DoChan = %+v; want shared 3", res)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn called %d times; want 1", got)
+	}
+}
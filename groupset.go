@@ -0,0 +1,86 @@
+package singleflight
+
+import (
+	"sort"
+	"sync"
+)
+
+// GroupSet is a registry of named groups created on demand.
+// All groups of the set share the same options (and therefore hooks),
+// and their statistics can be aggregated.
+type GroupSet[K comparable, V any] struct {
+	opts []Option[K, V]
+
+	mu     sync.RWMutex
+	groups map[string]*Group[K, V]
+}
+
+// NewGroupSet creates a set whose groups are configured with opts.
+func NewGroupSet[K comparable, V any](opts ...Option[K, V]) *GroupSet[K, V] {
+	return &GroupSet[K, V]{
+		opts:   opts,
+		groups: make(map[string]*Group[K, V]),
+	}
+}
+
+// Get returns the group with the given name, creating it if necessary.
+func (s *GroupSet[K, V]) Get(name string) *Group[K, V] {
+	s.mu.RLock()
+	g, ok := s.groups[name]
+	s.mu.RUnlock()
+	if ok {
+		return g
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok = s.groups[name]; !ok {
+		g = NewGroup(append([]Option[K, V]{WithName[K, V](name)}, s.opts...)...)
+		s.groups[name] = g
+	}
+	return g
+}
+
+// Names returns the sorted names of the groups created so far.
+func (s *GroupSet[K, V]) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the statistics of all groups of the set added together.
+func (s *GroupSet[K, V]) Stats() Stats {
+	var total Stats
+	for _, st := range s.GroupStats() {
+		total = total.Add(st)
+	}
+	return total
+}
+
+// GroupStats returns the statistics of each group by name.
+func (s *GroupSet[K, V]) GroupStats() map[string]Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[string]Stats, len(s.groups))
+	for name, g := range s.groups {
+		stats[name] = g.Stats()
+	}
+	return stats
+}
+
+// Close closes all groups of the set.
+func (s *GroupSet[K, V]) Close() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, g := range s.groups {
+		g.Close()
+	}
+}
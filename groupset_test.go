@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupSet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var finished atomic.Int32
+	set := NewGroupSet(WithHooks[string, int](Hooks[string]{
+		OnFinish: func(string, any, time.Duration, int, error) {
+			finished.Add(1)
+		},
+	}))
+
+	users := set.Get("users")
+	if set.Get("users") != users {
+		t.Error("Get returned different groups for the same name")
+	}
+	orders := set.Get("orders")
+
+	_, _, _ = users.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil })
+	_, _, _ = orders.Do(ctx, "key", func(context.Context) (int, error) { return 0, errors.New("fail") })
+
+	if got := finished.Load(); got != 2 {
+		t.Errorf("shared OnFinish hook called %d times; want 2", got)
+	}
+
+	names := set.Names()
+	if len(names) != 2 || names[0] != "orders" || names[1] != "users" {
+		t.Errorf("Names = %v; want [orders users]", names)
+	}
+
+	total := set.Stats()
+	if total.Calls != 2 || total.Executions != 2 || total.Errors != 1 {
+		t.Errorf("Stats = %+v; want 2 calls, 2 executions and 1 error", total)
+	}
+	if st := set.GroupStats()["orders"]; st.Errors != 1 {
+		t.Errorf("orders stats = %+v; want 1 error", st)
+	}
+
+	set.Close()
+	if _, _, err := users.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Do after Close error = %v; want %v", err, ErrClosed)
+	}
+}
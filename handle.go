@@ -0,0 +1,87 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ResourceGroup is a Group for values which are resources (connections, mmapped files):
+// every caller sharing a result gets its own Handle, and the release function is
+// called once the last handle is closed, so closable resources can be shared safely.
+type ResourceGroup[K comparable, V any] struct {
+	group   Group[K, *resource[V]]
+	release func(V)
+}
+
+// resource is a value shared by several handles.
+type resource[V any] struct {
+	val     V
+	refs    atomic.Int32
+	release func(V)
+}
+
+func (r *resource[V]) unref() {
+	if r.refs.Add(-1) == 0 && r.release != nil {
+		r.release(r.val)
+	}
+}
+
+// Handle is a reference to a shared resource, see ResourceGroup.
+type Handle[V any] struct {
+	res    *resource[V]
+	closed atomic.Bool
+}
+
+// Value returns the resource. It must not be used after Close.
+func (h *Handle[V]) Value() V {
+	return h.res.val
+}
+
+// Close drops the reference. The resource is released when all handles are closed.
+// Close is idempotent.
+func (h *Handle[V]) Close() error {
+	if h.closed.CompareAndSwap(false, true) {
+		h.res.unref()
+	}
+	return nil
+}
+
+// NewResourceGroup creates a ResourceGroup calling release for each resource
+// once all its handles are closed.
+func NewResourceGroup[K comparable, V any](release func(V)) *ResourceGroup[K, V] {
+	return &ResourceGroup[K, V]{release: release}
+}
+
+// DoShared executes fn for key like Group.Do and returns a handle on the shared resource.
+// The caller must close the handle when done with it. On error no handle is returned
+// and nothing is released.
+func (g *ResourceGroup[K, V]) DoShared(ctx context.Context, key K, fn doFunc[V]) (h *Handle[V], shared bool, err error) {
+	c, leader, callCtx, err := g.group.register(ctx, key, nil, nil, true)
+	if err != nil {
+		return nil, false, err
+	}
+	if !leader {
+		c.wg.Wait()
+		if c.err != nil {
+			return nil, true, c.err
+		}
+		return &Handle[V]{res: c.val}, true, nil
+	}
+
+	res := &resource[V]{release: g.release}
+	c.onShare = func(dups int) {
+		res.refs.Store(int32(dups + 1))
+	}
+	g.group.doCall(callCtx, c, key, func(ctx context.Context) (*resource[V], error) {
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res.val = v
+		return res, nil
+	})
+	if c.err != nil {
+		return nil, c.dups > 0, c.err
+	}
+	return &Handle[V]{res: res}, c.dups > 0, nil
+}
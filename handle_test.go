@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResourceGroup(t *testing.T) {
+	t.Parallel()
+
+	released := make(chan string, 1)
+	g := NewResourceGroup[string, string](func(conn string) { released <- conn })
+
+	release := make(chan struct{})
+	const n = 4
+	handles := make(chan *Handle[string], n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, _, err := g.DoShared(context.Background(), "db", func(context.Context) (string, error) {
+				<-release
+				return "conn", nil
+			})
+			if err != nil {
+				t.Errorf("DoShared error = %v", err)
+				return
+			}
+			handles <- h
+		}()
+	}
+	for g.group.Stats().Calls < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(handles)
+
+	var all []*Handle[string]
+	for h := range handles {
+		if h.Value() != "conn" {
+			t.Errorf("Value = %q; want conn", h.Value())
+		}
+		all = append(all, h)
+	}
+	for i, h := range all {
+		_ = h.Close()
+		_ = h.Close() // idempotent
+		select {
+		case conn := <-released:
+			if i != len(all)-1 {
+				t.Fatalf("%s released after %d of %d handles closed", conn, i+1, len(all))
+			}
+		default:
+			if i == len(all)-1 {
+				t.Fatal("resource not released after the last handle closed")
+			}
+		}
+	}
+}
+
+func TestResourceGroupError(t *testing.T) {
+	t.Parallel()
+
+	g := NewResourceGroup[string, string](func(string) { t.Error("nothing must be released") })
+	someErr := errors.New("some error")
+	h, _, err := g.DoShared(context.Background(), "db", func(context.Context) (string, error) { return "", someErr })
+	if h != nil || !errors.Is(err, someErr) {
+		t.Errorf("DoShared = %v, %v; want nil, %v", h, err, someErr)
+	}
+}
@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWaitBuckets are the bucket upper bounds used by NewHistogram without bounds,
+// from 1ms to 10s.
+var DefaultWaitBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Histogram counts durations in buckets with fixed upper bounds.
+// It is safe for concurrent use.
+type Histogram struct {
+	bounds []time.Duration
+	counts []atomic.Uint64 // one per bound, and the last one for larger durations
+	sum    atomic.Int64
+}
+
+// HistogramSnapshot is the state of a Histogram at some point.
+type HistogramSnapshot struct {
+	// Bounds are the inclusive upper bounds of the buckets, in increasing order.
+	Bounds []time.Duration `json:"bounds"`
+	// Counts are the number of durations in each bucket (not cumulative),
+	// the last one counting the durations above the last bound.
+	Counts []uint64      `json:"counts"`
+	Count  uint64        `json:"count"`
+	Sum    time.Duration `json:"sum"`
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds,
+// or DefaultWaitBuckets if there are none.
+func NewHistogram(bounds ...time.Duration) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultWaitBuckets
+	}
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds d to the histogram.
+func (h *Histogram) Observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Snapshot returns the current state of the histogram.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Bounds: slices.Clone(h.bounds),
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+		s.Count += s.Counts[i]
+	}
+	return s
+}
+
+// WaitHistogram returns hooks recording in h how long callers joining an in-flight call
+// waited for the shared result: the latency coalescing actually costs users.
+func WaitHistogram[K comparable](h *Histogram) Hooks[K] {
+	return Hooks[K]{
+		OnWait: func(_ K, _ any, d time.Duration, _ error) {
+			h.Observe(d)
+		},
+	}
+}
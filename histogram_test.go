@@ -0,0 +1,71 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistogram(10*time.Millisecond, time.Millisecond)
+	h.Observe(time.Millisecond)
+	h.Observe(5 * time.Millisecond)
+	h.Observe(time.Second)
+
+	s := h.Snapshot()
+	if s.Bounds[0] != time.Millisecond || s.Bounds[1] != 10*time.Millisecond {
+		t.Errorf("bounds = %v; want sorted", s.Bounds)
+	}
+	if len(s.Counts) != 3 || s.Counts[0] != 1 || s.Counts[1] != 1 || s.Counts[2] != 1 {
+		t.Errorf("counts = %v; want [1 1 1]", s.Counts)
+	}
+	if s.Count != 3 || s.Sum != time.Second+6*time.Millisecond {
+		t.Errorf("count, sum = %d, %v; want 3, 1.006s", s.Count, s.Sum)
+	}
+}
+
+func TestWaitHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistogram()
+	g := NewGroup(WithHooks[string, int](WaitHistogram[string](h)))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) { return 3, nil })
+	}()
+	for g.Stats().Shared < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	<-ch
+	wg.Wait()
+
+	s := h.Snapshot()
+	if s.Count != 2 {
+		t.Fatalf("waits recorded = %d; want 2 joined callers", s.Count)
+	}
+	if s.Sum < 10*time.Millisecond {
+		t.Errorf("total wait = %v; want at least 10ms", s.Sum)
+	}
+}
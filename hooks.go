@@ -0,0 +1,92 @@
+package singleflight
+
+import "time"
+
+// Hooks are optional callbacks observing the life cycle of calls.
+// Nil callbacks are ignored. Callbacks are invoked synchronously
+// from the goroutines of the callers and must not block.
+// They receive the raw key, e.g. to classify it, and its label, the key rendered
+// by the key redactor of the group, which is the one to log or tag, see WithKeyRedactor.
+type Hooks[K comparable] struct {
+	// OnStart is called when the function for key starts executing.
+	OnStart func(key K, label any)
+	// OnJoin is called when a caller joins an in-flight call for key.
+	OnJoin func(key K, label any)
+	// OnFinish is called when the function for key returns.
+	// d is the execution time and dups is the number of callers which shared the result.
+	OnFinish func(key K, label any, d time.Duration, dups int, err error)
+	// OnWait is called when a caller which joined the call for key receives the shared result.
+	// d is the time it waited, the latency the caller perceives, see WaitHistogram.
+	OnWait func(key K, label any, d time.Duration, err error)
+}
+
+// WithHooks adds hooks to the group.
+// The option can be used several times, all hooks are called in order.
+func WithHooks[K comparable, V any](h Hooks[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// hookList is the list of hooks registered for a group.
+type hookList[K comparable] []Hooks[K]
+
+func (l hookList[K]) start(key K, label any) {
+	for _, h := range l {
+		if h.OnStart != nil {
+			h.OnStart(key, label)
+		}
+	}
+}
+
+func (l hookList[K]) join(key K, label any) {
+	for _, h := range l {
+		if h.OnJoin != nil {
+			h.OnJoin(key, label)
+		}
+	}
+}
+
+func (l hookList[K]) finish(key K, label any, d time.Duration, dups int, err error) {
+	for _, h := range l {
+		if h.OnFinish != nil {
+			h.OnFinish(key, label, d, dups, err)
+		}
+	}
+}
+
+func (l hookList[K]) wait(key K, label any, d time.Duration, err error) {
+	for _, h := range l {
+		if h.OnWait != nil {
+			h.OnWait(key, label, d, err)
+		}
+	}
+}
+
+// hookStart calls the OnStart hooks if the call c is sampled.
+func (g *Group[K, V]) hookStart(c *call[V], key K) {
+	if hooks := g.options().hooks; c.sampled && len(hooks) > 0 {
+		hooks.start(key, g.keyLabel(key))
+	}
+}
+
+// hookJoin calls the OnJoin hooks if the call c is sampled.
+func (g *Group[K, V]) hookJoin(c *call[V], key K) {
+	if hooks := g.options().hooks; c.sampled && len(hooks) > 0 {
+		hooks.join(key, g.keyLabel(key))
+	}
+}
+
+// hookFinish calls the OnFinish hooks if the call c is sampled.
+func (g *Group[K, V]) hookFinish(c *call[V], key K, dups int) {
+	if hooks := g.options().hooks; c.sampled && len(hooks) > 0 {
+		hooks.finish(key, g.keyLabel(key), time.Since(c.started), dups, c.err)
+	}
+}
+
+// hookWait calls the OnWait hooks if the call c is sampled.
+func (g *Group[K, V]) hookWait(c *call[V], key K, d time.Duration) {
+	if hooks := g.options().hooks; c.sampled && len(hooks) > 0 {
+		hooks.wait(key, g.keyLabel(key), d, c.err)
+	}
+}
@@ -0,0 +1,68 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHooksAndStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		mu     sync.Mutex
+		events []string
+		dups   int
+	)
+	finished := make(chan struct{})
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	g := NewGroup(WithHooks[string, int](Hooks[string]{
+		OnStart: func(string, any) { record("start") },
+		OnJoin:  func(string, any) { record("join") },
+		OnFinish: func(_ string, _ any, _ time.Duration, n int, _ error) {
+			mu.Lock()
+			dups = n
+			mu.Unlock()
+			record("finish")
+			close(finished)
+		},
+	}))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	joined := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		t.Error("duplicate call must not be executed")
+		return 0, nil
+	})
+
+	if st := g.Stats(); st.Calls != 2 || st.Executions != 1 || st.Shared != 1 || st.InFlight != 1 {
+		t.Errorf("Stats = %+v; want 2 calls, 1 execution, 1 shared, 1 in flight", st)
+	}
+
+	close(release)
+	<-ch
+	<-joined
+	<-finished
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 3 || events[0] != "start" || events[1] != "join" || events[2] != "finish" {
+		t.Errorf("events = %v; want [start join finish]", events)
+	}
+	if dups != 1 {
+		t.Errorf("OnFinish dups = %d; want 1", dups)
+	}
+}
@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Idempotent coalesces idempotent writes: concurrent mutations carrying the same
+// idempotency key execute once and share the outcome, and successful outcomes are
+// retained for a short window, so slightly delayed duplicates (client retries,
+// redelivered messages) receive the stored result instead of executing again.
+// Failed writes are not retained, so they can be retried.
+type Idempotent[K comparable, V any] struct {
+	group     *Group[K, V]
+	retention time.Duration
+
+	mu        sync.Mutex
+	retained  *lru[K, V]
+	nextSweep time.Time // see store
+}
+
+// NewIdempotent creates an Idempotent retaining successful outcomes for retention,
+// for at most size keys (0 means unbounded). Expired outcomes are reclaimed even if
+// their keys are never looked up again. opts configure the underlying Group.
+func NewIdempotent[K comparable, V any](retention time.Duration, size int, opts ...Option[K, V]) *Idempotent[K, V] {
+	return &Idempotent[K, V]{
+		group:     NewGroup(opts...),
+		retention: retention,
+		retained:  newLRU[K, V](size),
+	}
+}
+
+// Do executes the write fn for the idempotency key unless a write with the same key
+// is in flight or succeeded within the retention window, in which case its outcome
+// is returned and shared is true.
+func (i *Idempotent[K, V]) Do(ctx context.Context, key K, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	if v, ok := i.lookup(key); ok {
+		return v, true, nil
+	}
+
+	return i.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		// a duplicate may have completed between lookup and Do
+		if v, ok := i.lookup(key); ok {
+			return v, nil
+		}
+		v, err := fn(ctx)
+		if err == nil && i.retention > 0 {
+			i.store(key, v)
+		}
+		return v, err
+	})
+}
+
+// store retains the outcome v for key. Once per retention window, it also deletes
+// the expired outcomes, so the memory is bounded by the keys written within
+// two retention windows.
+func (i *Idempotent[K, V]) store(key K, v V) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	if !now.Before(i.nextSweep) {
+		i.retained.sweep(now)
+		i.nextSweep = now.Add(i.retention)
+	}
+	i.retained.add(key, v, now.Add(i.retention))
+}
+
+// lookup returns the retained outcome for key.
+func (i *Idempotent[K, V]) lookup(key K) (V, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.retained.get(key, time.Now())
+}
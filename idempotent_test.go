@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := NewIdempotent[string, int](time.Minute, 0)
+
+	var writes atomic.Int32
+	write := func(context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return int(writes.Add(1)), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := w.Do(ctx, "payment-1", write); v != 1 || err != nil {
+				t.Errorf("Do = %v, %v; want 1, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// delayed duplicate
+	v, shared, err := w.Do(ctx, "payment-1", write)
+	if v != 1 || !shared || err != nil {
+		t.Errorf("delayed duplicate = %v, %v, %v; want retained 1, true, nil", v, shared, err)
+	}
+	if got := writes.Load(); got != 1 {
+		t.Errorf("writes = %d; want 1", got)
+	}
+}
+
+func TestIdempotentFailureNotRetained(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := NewIdempotent[string, int](time.Minute, 0)
+	someErr := errors.New("some error")
+
+	if _, _, err := w.Do(ctx, "key", func(context.Context) (int, error) { return 0, someErr }); !errors.Is(err, someErr) {
+		t.Fatalf("error = %v; want %v", err, someErr)
+	}
+	if v, shared, err := w.Do(ctx, "key", func(context.Context) (int, error) { return 2, nil }); v != 2 || shared || err != nil {
+		t.Errorf("retry = %v, %v, %v; want 2, false, nil", v, shared, err)
+	}
+}
+
+func TestIdempotentExpiredReclaimed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := NewIdempotent[string, int](10*time.Millisecond, 0)
+	write := func(context.Context) (int, error) { return 1, nil }
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, _ = w.Do(ctx, key, write)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_, _, _ = w.Do(ctx, "d", write)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n := w.retained.len(); n != 1 {
+		t.Errorf("retained = %d; want only the outcome of the last write", n)
+	}
+}
@@ -0,0 +1,45 @@
+package singleflight
+
+import "time"
+
+// WithLastResults retains the result of the most recent completed call for up
+// to size keys (least recently used keys are dropped first) for at most ttl.
+// A zero ttl keeps results until they are dropped by size.
+// Retained results are available through LastResult.
+func WithLastResults[K comparable, V any](size int, ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.lastSize = size
+		o.lastTTL = ttl
+	}
+}
+
+// LastResult returns the result of the most recent completed call for key,
+// if it is still retained. It never triggers an execution, so it suits
+// monitoring endpoints and best-effort reads.
+// Results are retained only if the group was created with WithLastResults.
+func (g *Group[K, V]) LastResult(key K) (Result[V], bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.last == nil {
+		return Result[V]{}, false
+	}
+	return g.last.get(key, time.Now())
+}
+
+// storeLastResult retains the result of a completed call.
+// Must be called with g.mu held.
+func (g *Group[K, V]) storeLastResult(key K, res Result[V]) {
+	if g.options().lastSize <= 0 {
+		return
+	}
+	if g.last == nil {
+		g.last = newLRU[K, Result[V]](g.options().lastSize)
+	}
+
+	var expires time.Time
+	if g.options().lastTTL > 0 {
+		expires = time.Now().Add(g.options().lastTTL)
+	}
+	g.last.add(key, res, expires)
+}
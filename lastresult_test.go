@@ -0,0 +1,66 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLastResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithLastResults[string, int](2, 0))
+
+	if _, ok := g.LastResult("a"); ok {
+		t.Error("LastResult before any call must not be found")
+	}
+
+	someErr := errors.New("some error")
+	_, _, _ = g.Do(ctx, "a", func(context.Context) (int, error) { return 1, nil })
+	_, _, _ = g.Do(ctx, "b", func(context.Context) (int, error) { return 0, someErr })
+
+	if res, ok := g.LastResult("a"); !ok || res.Val != 1 || res.Err != nil {
+		t.Errorf("LastResult(a) = %+v, %v; want value 1", res, ok)
+	}
+	if res, ok := g.LastResult("b"); !ok || !errors.Is(res.Err, someErr) {
+		t.Errorf("LastResult(b) = %+v, %v; want error %v", res, ok, someErr)
+	}
+
+	// "b" was used more recently than "a", so "a" is dropped.
+	_, _, _ = g.Do(ctx, "c", func(context.Context) (int, error) { return 3, nil })
+	if _, ok := g.LastResult("a"); ok {
+		t.Error("LastResult(a) must be dropped by size")
+	}
+	if res, ok := g.LastResult("c"); !ok || res.Val != 3 {
+		t.Errorf("LastResult(c) = %+v, %v; want value 3", res, ok)
+	}
+}
+
+func TestLastResultTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithLastResults[string, int](10, 10*time.Millisecond))
+
+	_, _, _ = g.Do(ctx, "a", func(context.Context) (int, error) { return 1, nil })
+	if _, ok := g.LastResult("a"); !ok {
+		t.Error("LastResult(a) must be retained")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := g.LastResult("a"); ok {
+		t.Error("LastResult(a) must expire")
+	}
+}
+
+func TestLastResultDisabled(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	_, _, _ = g.Do(context.Background(), "a", func(context.Context) (int, error) { return 1, nil })
+	if _, ok := g.LastResult("a"); ok {
+		t.Error("results must not be retained without WithLastResults")
+	}
+}
@@ -0,0 +1,72 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// latencyWeight is the weight of a new observation in the moving average of execution times.
+const latencyWeight = 0.3
+
+// WithLatencyEstimates keeps an exponentially weighted moving average of the execution
+// time of the size most recently executed keys (or key classes, see WithLatencyClass),
+// available through Estimate. Canceled executions are ignored.
+func WithLatencyEstimates[K comparable, V any](size int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.latency = true
+		o.latencySize = size
+	}
+}
+
+// WithLatencyClass shares latency estimates between keys: class maps a key to the
+// representative key of its class, e.g. "user:42" to "user:*".
+func WithLatencyClass[K comparable, V any](class func(K) K) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.latencyClass = class
+	}
+}
+
+// Estimate returns the expected execution time of key, if latency estimates are enabled
+// and key (or its class) was executed before.
+func (g *Group[K, V]) Estimate(key K) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.estimate(key)
+}
+
+// estimate implements Estimate.
+// Must be called with g.mu held.
+func (g *Group[K, V]) estimate(key K) (time.Duration, bool) {
+	if g.latencies == nil {
+		return 0, false
+	}
+	if g.options().latencyClass != nil {
+		key = g.options().latencyClass(key)
+	}
+	return g.latencies.get(key, time.Time{})
+}
+
+// observeLatency updates the execution time estimate of key with the duration d
+// of an execution which returned err. Canceled executions are ignored.
+// Must be called with g.mu held.
+func (g *Group[K, V]) observeLatency(key K, d time.Duration, err error) {
+	if !g.options().latency {
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if g.options().latencyClass != nil {
+		key = g.options().latencyClass(key)
+	}
+	if g.latencies == nil {
+		g.latencies = newLRU[K, time.Duration](g.options().latencySize)
+	}
+	if prev, ok := g.latencies.get(key, time.Time{}); ok {
+		d = time.Duration(latencyWeight*float64(d) + (1-latencyWeight)*float64(prev))
+	}
+	g.latencies.add(key, d, time.Time{})
+}
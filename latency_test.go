@@ -0,0 +1,41 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(
+		WithLatencyEstimates[string, int](10),
+		WithLatencyClass[string, int](func(key string) string {
+			if i := strings.IndexByte(key, ':'); i >= 0 {
+				return key[:i] + ":*"
+			}
+			return key
+		}),
+	)
+
+	if _, ok := g.Estimate("user:1"); ok {
+		t.Error("Estimate of an unknown key must not be available")
+	}
+
+	_, _, _ = g.Do(ctx, "user:1", func(context.Context) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	d, ok := g.Estimate("user:2") // same class
+	if !ok || d < 20*time.Millisecond {
+		t.Errorf("Estimate = %v, %v; want at least 20ms", d, ok)
+	}
+
+	_, _, _ = g.Do(ctx, "user:3", func(context.Context) (int, error) { return 1, nil })
+	if d2, _ := g.Estimate("user:1"); d2 >= d {
+		t.Errorf("Estimate after a fast execution = %v; want less than %v", d2, d)
+	}
+}
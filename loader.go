@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// LoadFunc loads the value for a key.
+type LoadFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// LoaderOption configures a Loader created by NewLoader.
+type LoaderOption[K comparable, V any] func(*Loader[K, V])
+
+// Loader is a cached, deduplicated fetch behind a single Get call: it bundles a Cache
+// (and so a Group) with a load function bound at construction, early refresh
+// and negative caching, all configured with options.
+type Loader[K comparable, V any] struct {
+	load      LoadFunc[K, V]
+	cache     *Cache[K, V]
+	cacheOpts []CacheOption[K, V]
+
+	negTTL    time.Duration
+	negSize   int
+	mu        sync.Mutex
+	negatives *lru[K, error]
+}
+
+// NewLoader creates a Loader caching the values returned by load for ttl.
+func NewLoader[K comparable, V any](load LoadFunc[K, V], ttl time.Duration, opts ...LoaderOption[K, V]) *Loader[K, V] {
+	l := &Loader[K, V]{load: load}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.cache = NewCache(ttl, l.cacheOpts...)
+	l.negatives = newLRU[K, error](l.negSize)
+	return l
+}
+
+// WithCacheOptions configures the cache of the loader, e.g. with
+// WithCacheSize or WithEarlyRefresh.
+func WithCacheOptions[K comparable, V any](opts ...CacheOption[K, V]) LoaderOption[K, V] {
+	return func(l *Loader[K, V]) {
+		l.cacheOpts = append(l.cacheOpts, opts...)
+	}
+}
+
+// WithNegativeCaching caches load errors for ttl, for at most size keys (0 means unbounded),
+// so a failing key doesn't hit the backend on every Get.
+// Context cancellation errors are never cached.
+func WithNegativeCaching[K comparable, V any](ttl time.Duration, size int) LoaderOption[K, V] {
+	return func(l *Loader[K, V]) {
+		l.negTTL = ttl
+		l.negSize = size
+	}
+}
+
+// Get returns the value for key, loading it if it is not cached.
+func (l *Loader[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if l.negTTL > 0 {
+		l.mu.Lock()
+		err, ok := l.negatives.get(key, time.Now())
+		l.mu.Unlock()
+		if ok {
+			var zero V
+			return zero, err
+		}
+	}
+
+	v, err := l.cache.Get(ctx, key, func(ctx context.Context) (V, error) {
+		v, err := l.load(ctx, key)
+		if err != nil && l.negTTL > 0 && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			l.mu.Lock()
+			l.negatives.add(key, err, time.Now().Add(l.negTTL))
+			l.mu.Unlock()
+		}
+		return v, err
+	})
+	return v, err
+}
+
+// Invalidate drops the cached value or error for key.
+func (l *Loader[K, V]) Invalidate(key K) {
+	l.cache.Forget(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.negatives.remove(key)
+}
@@ -0,0 +1,73 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	l := NewLoader(func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	}, time.Minute, WithCacheOptions(WithCacheSize[string, int](10)))
+
+	for i := 0; i < 3; i++ {
+		if v, err := l.Get(ctx, "abc"); v != 3 || err != nil {
+			t.Errorf("Get = %v, %v; want 3, nil", v, err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times; want 1", got)
+	}
+
+	l.Invalidate("abc")
+	_, _ = l.Get(ctx, "abc")
+	if got := calls.Load(); got != 2 {
+		t.Errorf("load called %d times after Invalidate; want 2", got)
+	}
+}
+
+func TestLoaderNegativeCaching(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+
+	var calls atomic.Int32
+	l := NewLoader(func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		if key == "canceled" {
+			return 0, context.Canceled
+		}
+		return 0, someErr
+	}, time.Minute, WithNegativeCaching[string, int](time.Minute, 0))
+
+	for i := 0; i < 3; i++ {
+		if _, err := l.Get(ctx, "bad"); !errors.Is(err, someErr) {
+			t.Errorf("Get error = %v; want %v", err, someErr)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times; want 1 with negative caching", got)
+	}
+
+	_, _ = l.Get(ctx, "canceled")
+	_, _ = l.Get(ctx, "canceled")
+	if got := calls.Load(); got != 3 {
+		t.Errorf("load called %d times; context errors must not be cached", got)
+	}
+
+	l.Invalidate("bad")
+	_, _ = l.Get(ctx, "bad")
+	if got := calls.Load(); got != 4 {
+		t.Errorf("load called %d times after Invalidate; want 4", got)
+	}
+}
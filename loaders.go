@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownLoader is matched by the errors returned when calling a loader which
+// is not registered, see LoaderRegistry.
+var ErrUnknownLoader = errors.New("singleflight: unknown loader")
+
+// LoaderRegistry holds named loaders declared once at startup and referenced by name
+// at call sites, so the configuration of each loader (timeouts, caching) is centralized
+// and call sites don't build ad-hoc closures. The zero value is ready to use.
+type LoaderRegistry[K comparable, V any] struct {
+	mu      sync.RWMutex
+	loaders map[string]LoadFunc[K, V] // lazily initialized
+}
+
+// RegisterLoader registers fn under name. Calls are deduplicated through a group
+// configured with opts, e.g. WithTimeout.
+// It fails if a loader is already registered under name.
+func (r *LoaderRegistry[K, V]) RegisterLoader(name string, fn LoadFunc[K, V], opts ...Option[K, V]) error {
+	g := NewGroupFunc(fn, opts...)
+	return r.register(name, func(ctx context.Context, key K) (V, error) {
+		v, _, err := g.Do(ctx, key)
+		return v, err
+	})
+}
+
+// RegisterCachedLoader registers l, which caches the loaded values, under name.
+// It fails if a loader is already registered under name.
+func (r *LoaderRegistry[K, V]) RegisterCachedLoader(name string, l *Loader[K, V]) error {
+	return r.register(name, l.Get)
+}
+
+// Call loads the value for key with the loader registered under name.
+func (r *LoaderRegistry[K, V]) Call(ctx context.Context, name string, key K) (V, error) {
+	r.mu.RLock()
+	load, ok := r.loaders[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		var zero V
+		return zero, fmt.Errorf("%w %q", ErrUnknownLoader, name)
+	}
+	return load(ctx, key)
+}
+
+// Names returns the sorted names of the registered loaders.
+func (r *LoaderRegistry[K, V]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.loaders))
+	for name := range r.loaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// register registers load under name.
+func (r *LoaderRegistry[K, V]) register(name string, load LoadFunc[K, V]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.loaders[name]; ok {
+		return fmt.Errorf("singleflight: loader %q already registered", name)
+	}
+	if r.loaders == nil {
+		r.loaders = make(map[string]LoadFunc[K, V])
+	}
+	r.loaders[name] = load
+	return nil
+}
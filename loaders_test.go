@@ -0,0 +1,51 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoaderRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var r LoaderRegistry[int, string]
+
+	if err := r.RegisterLoader("itoa", func(_ context.Context, key int) (string, error) {
+		return strconv.Itoa(key), nil
+	}, WithTimeout[int, string](time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var loads atomic.Int32
+	if err := r.RegisterCachedLoader("cached", NewLoader(func(_ context.Context, key int) (string, error) {
+		loads.Add(1)
+		return "v" + strconv.Itoa(key), nil
+	}, time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterLoader("itoa", nil); err == nil {
+		t.Error("registering a name twice must fail")
+	}
+
+	if v, err := r.Call(ctx, "itoa", 42); v != "42" || err != nil {
+		t.Errorf("Call(itoa) = %q, %v; want 42, nil", v, err)
+	}
+	for range 2 {
+		if v, err := r.Call(ctx, "cached", 1); v != "v1" || err != nil {
+			t.Errorf("Call(cached) = %q, %v; want v1, nil", v, err)
+		}
+	}
+	if got := loads.Load(); got != 1 {
+		t.Errorf("cached loader called %d times; want 1", got)
+	}
+	if _, err := r.Call(ctx, "missing", 1); !errors.Is(err, ErrUnknownLoader) {
+		t.Errorf("Call(missing) error = %v; want %v", err, ErrUnknownLoader)
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "cached" || names[1] != "itoa" {
+		t.Errorf("Names = %v; want [cached itoa]", names)
+	}
+}
@@ -0,0 +1,119 @@
+package singleflight
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru is a size-bounded least recently used map with optional expiration of entries.
+// It is not safe for concurrent use.
+type lru[K comparable, V any] struct {
+	size  int // maximum number of entries, 0 means unbounded
+	ll    *list.List
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	val     V
+	expires time.Time // zero means never
+}
+
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+// add inserts or replaces the entry for key and returns the entries evicted to respect the size.
+func (l *lru[K, V]) add(key K, val V, expires time.Time) (evicted []lruEntry[K, V]) {
+	if e, ok := l.items[key]; ok {
+		l.ll.MoveToFront(e)
+		ent := e.Value.(*lruEntry[K, V]) // nolint: forcetypeassert
+		ent.val = val
+		ent.expires = expires
+		return nil
+	}
+
+	l.items[key] = l.ll.PushFront(&lruEntry[K, V]{key: key, val: val, expires: expires})
+	for l.size > 0 && l.ll.Len() > l.size {
+		evicted = append(evicted, l.removeElement(l.ll.Back()))
+	}
+	return evicted
+}
+
+// get returns the value for key if it is present and not expired at now.
+// Expired entries are removed.
+func (l *lru[K, V]) get(key K, now time.Time) (V, bool) {
+	ent, ok := l.getEntry(key, now)
+	return ent.val, ok
+}
+
+// getEntry is like get but returns the whole entry.
+func (l *lru[K, V]) getEntry(key K, now time.Time) (lruEntry[K, V], bool) {
+	e, ok := l.items[key]
+	if !ok {
+		return lruEntry[K, V]{}, false
+	}
+	ent := e.Value.(*lruEntry[K, V]) // nolint: forcetypeassert
+	if !ent.expires.IsZero() && !now.Before(ent.expires) {
+		l.removeElement(e)
+		return lruEntry[K, V]{}, false
+	}
+	l.ll.MoveToFront(e)
+	return *ent, true
+}
+
+// removeExpired deletes the entry for key if it is expired at now, and returns it.
+func (l *lru[K, V]) removeExpired(key K, now time.Time) (lruEntry[K, V], bool) {
+	e, ok := l.items[key]
+	if !ok {
+		return lruEntry[K, V]{}, false
+	}
+	ent := e.Value.(*lruEntry[K, V]) // nolint: forcetypeassert
+	if ent.expires.IsZero() || now.Before(ent.expires) {
+		return lruEntry[K, V]{}, false
+	}
+	return l.removeElement(e), true
+}
+
+// sweep deletes all entries expired at now.
+func (l *lru[K, V]) sweep(now time.Time) {
+	for e := l.ll.Back(); e != nil; {
+		prev := e.Prev()
+		ent := e.Value.(*lruEntry[K, V]) // nolint: forcetypeassert
+		if !ent.expires.IsZero() && !now.Before(ent.expires) {
+			l.removeElement(e)
+		}
+		e = prev
+	}
+}
+
+// remove deletes the entry for key.
+func (l *lru[K, V]) remove(key K) (lruEntry[K, V], bool) {
+	e, ok := l.items[key]
+	if !ok {
+		return lruEntry[K, V]{}, false
+	}
+	return l.removeElement(e), true
+}
+
+func (l *lru[K, V]) removeElement(e *list.Element) lruEntry[K, V] {
+	l.ll.Remove(e)
+	ent := e.Value.(*lruEntry[K, V]) // nolint: forcetypeassert
+	delete(l.items, ent.key)
+	return *ent
+}
+
+// each calls fn for every entry, from the least to the most recently used.
+func (l *lru[K, V]) each(fn func(ent lruEntry[K, V])) {
+	for e := l.ll.Back(); e != nil; e = e.Prev() {
+		fn(*e.Value.(*lruEntry[K, V])) // nolint: forcetypeassert
+	}
+}
+
+func (l *lru[K, V]) len() int {
+	return l.ll.Len()
+}
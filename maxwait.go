@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrMaxWait is returned by DoMaxWait when the shared call did not complete in time.
+var ErrMaxWait = errors.New("singleflight: maximum wait exceeded")
+
+// DoMaxWait is like Do but a caller joining an in-flight call waits for it at most maxWait,
+// so one pathological leader can't hold its waiters hostage indefinitely.
+// On expiry the caller detaches from the call and, if runOwn is true, executes fn
+// independently as DoBypass without replacing the call, otherwise returns ErrMaxWait.
+// The leader itself is not limited.
+func (g *Group[K, V]) DoMaxWait(ctx context.Context, key K, maxWait time.Duration, fn doFunc[V], runOwn bool) (v V, shared bool, err error) { // nolint: revive
+	c, leader, callCtx, err := g.register(ctx, key, nil, nil, true)
+	if err != nil {
+		return v, false, err
+	}
+	if leader {
+		g.doCall(callCtx, c, key, fn)
+		return c.val, c.dups > 0, c.err
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+		return c.val, true, c.err
+	case <-timer.C:
+	}
+
+	if !g.detach(c, false, nil) {
+		// completed meanwhile
+		return c.val, true, c.err
+	}
+	if runOwn {
+		return g.DoBypass(ctx, key, fn, false)
+	}
+	return v, false, ErrMaxWait
+}
+
+// detach removes a caller from the call c, so it is not counted as sharing the result,
+// and removes its result channel ch if not nil. A leader has no duplicate to remove.
+// A call nobody is interested in anymore may be canceled, see WithCancelAbandoned.
+// It returns false if c has already completed or ch was already removed.
+func (g *Group[K, V]) detach(c *call[V], leader bool, ch chan<- Result[V]) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c.isDone() {
+		return false
+	}
+	if ch != nil {
+		i := slices.Index(c.chans, ch)
+		if i < 0 {
+			// already removed and answered, see WithPriorityShedding
+			return false
+		}
+		c.chans = slices.Delete(c.chans, i, i+1)
+		delete(c.joined, ch)
+		delete(c.priorities, ch)
+	}
+	if !leader {
+		c.dups--
+	}
+	if leader {
+		c.leaderGone = true
+	}
+	g.cancelAbandoned(c)
+	return true
+}
@@ -0,0 +1,43 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoMaxWait(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leaderDone := make(chan bool)
+	go func() {
+		_, shared, _ := g.Do(ctx, "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		leaderDone <- shared
+	}()
+	<-started
+
+	_, _, err := g.DoMaxWait(ctx, "key", 10*time.Millisecond, func(context.Context) (int, error) { return 2, nil }, false)
+	if !errors.Is(err, ErrMaxWait) {
+		t.Errorf("DoMaxWait error = %v; want %v", err, ErrMaxWait)
+	}
+
+	v, shared, err := g.DoMaxWait(ctx, "key", 10*time.Millisecond, func(context.Context) (int, error) { return 3, nil }, true)
+	if v != 3 || shared || err != nil {
+		t.Errorf("DoMaxWait with own execution = %v, %v, %v; want 3, false, nil", v, shared, err)
+	}
+
+	close(release)
+	if shared := <-leaderDone; shared {
+		t.Error("detached waiters must not count as sharing the result")
+	}
+}
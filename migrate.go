@@ -0,0 +1,37 @@
+package singleflight
+
+import "context"
+
+// Migrate hands the in-flight call for key over to dst, e.g. during tenant rebalancing or
+// a configuration swap: callers of dst arriving before it completes share its result instead
+// of executing the work again, while callers already waiting for it keep waiting seamlessly.
+// The key is forgotten in g. If dst already has an in-flight call for key, it is kept
+// and the migrated call is only forgotten.
+// It reports whether there was a call to migrate; registering it in dst may fail like Do,
+// e.g. with ErrClosed, and the call then stays in g.
+func (g *Group[K, V]) Migrate(key K, dst *Group[K, V]) (bool, error) {
+	g.mu.Lock()
+	c, ok := g.m[key]
+	g.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	proxy, leader, callCtx, err := dst.register(context.Background(), key, nil, nil, false)
+	if err != nil {
+		return true, err
+	}
+	if leader {
+		go dst.doCall(callCtx, proxy, key, func(context.Context) (V, error) {
+			<-c.done
+			return c.val, c.err
+		})
+	}
+
+	g.mu.Lock()
+	if g.m[key] == c {
+		delete(g.m, key)
+	}
+	g.mu.Unlock()
+	return true, nil
+}
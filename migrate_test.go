@@ -0,0 +1,57 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	var src, dst Group[string, int]
+	if ok, err := src.Migrate("key", &dst); ok || err != nil {
+		t.Errorf("Migrate without call = %v, %v; want false, nil", ok, err)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	waiting := src.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	if ok, err := src.Migrate("key", &dst); !ok || err != nil {
+		t.Fatalf("Migrate = %v, %v; want true, nil", ok, err)
+	}
+	joined := dst.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	fresh := src.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 3, nil })
+	close(release)
+
+	if res := <-waiting; res.Val != 1 {
+		t.Errorf("waiting caller = %+v; want 1", res)
+	}
+	if res := <-joined; res.Val != 1 || !res.Shared {
+		t.Errorf("caller of dst = %+v; want the migrated 1", res)
+	}
+	if res := <-fresh; res.Val != 3 {
+		t.Errorf("caller of src = %+v; want its own 3 after the key was forgotten", res)
+	}
+
+	closed := NewGroup[string, int]()
+	closed.Close()
+	started2 := make(chan struct{})
+	release2 := make(chan struct{})
+	defer close(release2)
+	src.DoChan(context.Background(), "other", func(context.Context) (int, error) {
+		close(started2)
+		<-release2
+		return 1, nil
+	})
+	<-started2
+	if ok, err := src.Migrate("other", closed); !ok || !errors.Is(err, ErrClosed) {
+		t.Errorf("Migrate to closed group = %v, %v; want true, %v", ok, err, ErrClosed)
+	}
+}
@@ -0,0 +1,151 @@
+package singleflight
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// sharedValue is a result shared by several callers, with its fingerprint.
+type sharedValue[V any] struct {
+	val V
+	sum uint64
+}
+
+// WithMutationDetection is a debug mode reporting shared results mutated by a caller,
+// the most common bug with singleflight and values holding pointers, slices or maps:
+// every caller receives the same value, so a caller modifying it changes it for the others.
+// The group fingerprints every result shared by several callers, following pointers,
+// and calls report with the key and the value if the fingerprint changed when
+// the next execution for the key completes or on CheckMutations.
+// Fingerprinting is expensive and one value per key is retained, so the mode is meant
+// for tests; run them with the race detector, which also reports concurrent mutations.
+func WithMutationDetection[K comparable, V any](report func(key K, v V)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onMutation = report
+	}
+}
+
+// CheckMutations verifies the shared results retained by WithMutationDetection,
+// reports the mutated ones and returns their number. The results are not checked again.
+func (g *Group[K, V]) CheckMutations() int {
+	g.mu.Lock()
+	var mutated []K
+	for key, shared := range g.shared {
+		if fingerprint(shared.val) != shared.sum {
+			mutated = append(mutated, key)
+		}
+	}
+	values := g.shared
+	g.shared = nil
+	g.mu.Unlock()
+
+	for _, key := range mutated {
+		g.options().onMutation(key, values[key].val)
+	}
+	return len(mutated)
+}
+
+// checkMutation verifies the previous shared result of key and retains the result of the
+// call c if it is shared. It returns the mutated value, if any, to report once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) checkMutation(c *call[V], key K) (v V, mutated bool) {
+	if g.options().onMutation == nil {
+		return v, false
+	}
+
+	if prev, ok := g.shared[key]; ok {
+		delete(g.shared, key)
+		if fingerprint(prev.val) != prev.sum {
+			v, mutated = prev.val, true
+		}
+	}
+	if c.dups > 0 && c.err == nil {
+		if g.shared == nil {
+			g.shared = make(map[K]sharedValue[V])
+		}
+		g.shared[key] = sharedValue[V]{val: c.val, sum: fingerprint(c.val)}
+	}
+	return v, mutated
+}
+
+// fingerprint returns a hash of the contents of v, following pointers.
+func fingerprint(v any) uint64 {
+	h := fnv.New64a()
+	hashValue(h, reflect.ValueOf(v), make(map[uintptr]struct{}))
+	return h.Sum64()
+}
+
+// hashValue writes the contents of v to h. Pointers already in visited are not followed again.
+func hashValue(h hash.Hash64, v reflect.Value, visited map[uintptr]struct{}) {
+	writeUint := func(x uint64) {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], x)
+		_, _ = h.Write(buf[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		writeUint(0)
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int())) // nolint: gosec
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		writeUint(math.Float64bits(real(v.Complex())))
+		writeUint(math.Float64bits(imag(v.Complex())))
+	case reflect.String:
+		writeUint(uint64(v.Len()))
+		_, _ = h.Write([]byte(v.String()))
+	case reflect.Pointer:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		writeUint(uint64(v.Pointer()))
+		if _, ok := visited[v.Pointer()]; ok {
+			return
+		}
+		visited[v.Pointer()] = struct{}{}
+		hashValue(h, v.Elem(), visited)
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		hashValue(h, v.Elem(), visited)
+	case reflect.Slice, reflect.Array:
+		writeUint(uint64(v.Len()))
+		for i := range v.Len() {
+			hashValue(h, v.Index(i), visited)
+		}
+	case reflect.Map:
+		// entries are combined independently of the iteration order
+		writeUint(uint64(v.Len()))
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			entry := fnv.New64a()
+			hashValue(entry, iter.Key(), visited)
+			hashValue(entry, iter.Value(), visited)
+			sum += entry.Sum64()
+		}
+		writeUint(sum)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			hashValue(h, v.Field(i), visited)
+		}
+	default: // chan, func, unsafe pointer
+		writeUint(uint64(v.Pointer()))
+	}
+}
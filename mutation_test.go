@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+// shareResult returns the result of a call for key shared by two callers.
+func shareResult(t *testing.T, g *Group[string, map[string][]int], key string) map[string][]int {
+	t.Helper()
+
+	ctx := context.Background()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(ctx, key, func(context.Context) (map[string][]int, error) {
+		close(started)
+		<-release
+		return map[string][]int{"a": {1, 2}}, nil
+	})
+	<-started
+	dup := g.DoChan(ctx, key, func(context.Context) (map[string][]int, error) { return nil, nil })
+	close(release)
+	<-dup
+	res := <-leader
+	if !res.Shared {
+		t.Fatal("result must be shared")
+	}
+	return res.Val
+}
+
+func TestMutationDetection(t *testing.T) {
+	t.Parallel()
+
+	var reported []string
+	g := NewGroup(WithMutationDetection(func(key string, _ map[string][]int) {
+		reported = append(reported, key)
+	}))
+
+	shareResult(t, g, "untouched")
+	v := shareResult(t, g, "mutated")
+	v["a"][1] = 3
+
+	if n := g.CheckMutations(); n != 1 || len(reported) != 1 || reported[0] != "mutated" {
+		t.Errorf("CheckMutations = %d, reported %v; want the mutated key", n, reported)
+	}
+	if n := g.CheckMutations(); n != 0 {
+		t.Errorf("second CheckMutations = %d; want 0", n)
+	}
+
+	v = shareResult(t, g, "next")
+	v["b"] = nil
+	_, _, _ = g.Do(context.Background(), "next", func(context.Context) (map[string][]int, error) { return nil, nil })
+	if len(reported) != 2 || reported[1] != "next" {
+		t.Errorf("reported %v; want the mutation detected by the next execution", reported)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		next *node
+		val  int
+	}
+	cycle := &node{val: 1}
+	cycle.next = cycle
+
+	before := fingerprint(cycle)
+	if fingerprint(cycle) != before {
+		t.Error("fingerprint must be stable")
+	}
+	cycle.val = 2
+	if fingerprint(cycle) == before {
+		t.Error("fingerprint must follow pointers")
+	}
+	if fingerprint(map[int]int{1: 1, 2: 2}) != fingerprint(map[int]int{2: 2, 1: 1}) {
+		t.Error("fingerprint of maps must not depend on the order")
+	}
+}
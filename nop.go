@@ -0,0 +1,41 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// Doer is implemented by Group and its drop-in alternatives, so code can depend on
+// the deduplication behavior without committing to it, see NopGroup.
+type Doer[K comparable, V any] interface {
+	Do(ctx context.Context, key K, fn doFunc[V]) (v V, shared bool, err error) // nolint: revive
+	DoChan(ctx context.Context, key K, fn doFunc[V]) <-chan Result[V]
+}
+
+var (
+	_ Doer[string, int] = (*Group[string, int])(nil)
+	_ Doer[string, int] = (*Sharded[string, int])(nil)
+	_ Doer[string, int] = NopGroup[string, int]{}
+)
+
+// NopGroup is a Doer executing every call without deduplication, e.g. to disable
+// singleflight behind a feature flag or in some environments without code changes.
+// Results are never shared. The zero value is ready to use.
+type NopGroup[K comparable, V any] struct{}
+
+// Do executes fn and returns its results.
+func (NopGroup[K, V]) Do(ctx context.Context, _ K, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	v, err = fn(ctx)
+	return v, false, err
+}
+
+// DoChan executes fn in a new goroutine and returns a channel receiving its results.
+func (NopGroup[K, V]) DoChan(ctx context.Context, _ K, fn doFunc[V]) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		started := time.Now()
+		v, err := fn(ctx)
+		ch <- Result[V]{Val: v, Err: err, SharedCount: 1, Attempts: 1, Started: started, Finished: time.Now()}
+	}()
+	return ch
+}
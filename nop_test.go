@@ -0,0 +1,67 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNopGroup(t *testing.T) {
+	t.Parallel()
+
+	var (
+		d       Doer[string, int] = NopGroup[string, int]{}
+		calls   atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	fn := func(context.Context) (int, error) {
+		<-release
+		return int(calls.Add(1)), nil
+	}
+
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, shared, _ := d.Do(context.Background(), "key", fn); shared {
+				t.Error("NopGroup result must not be shared")
+			}
+		}()
+	}
+	ch := d.DoChan(context.Background(), "key", fn)
+	close(release)
+	wg.Wait()
+
+	if res := <-ch; res.Shared || res.Err != nil {
+		t.Errorf("DoChan = %+v; want an unshared result", res)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("fn called %d times; want 4", got)
+	}
+}
+
+// fakeDoer implements Doer like a package outside singleflight would, spelling out the function type.
+type fakeDoer struct{}
+
+func (fakeDoer) Do(ctx context.Context, _ string, fn func(context.Context) (int, error)) (int, bool, error) {
+	v, err := fn(ctx)
+	return v, false, err
+}
+
+func (fakeDoer) DoChan(ctx context.Context, key string, fn func(context.Context) (int, error)) <-chan Result[int] {
+	ch := make(chan Result[int], 1)
+	v, _, err := fakeDoer{}.Do(ctx, key, fn)
+	ch <- Result[int]{Val: v, Err: err}
+	return ch
+}
+
+func TestDoerExternal(t *testing.T) {
+	t.Parallel()
+
+	var d Doer[string, int] = fakeDoer{}
+	if v, _, err := d.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do = %v, %v; want 1, nil", v, err)
+	}
+}
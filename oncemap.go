@@ -0,0 +1,84 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// OnceMap memoizes values per key forever: the first successful execution for a key is
+// computed through singleflight and its value is returned to all later callers,
+// like sync.Once keyed by K. Failed executions are not memoized and are retried
+// by the next caller. The zero value is ready to use.
+type OnceMap[K comparable, V any] struct {
+	group Group[K, V]
+
+	mu     sync.RWMutex
+	values map[K]V // lazily initialized
+}
+
+// Get returns the value memoized for key, computing it with fn if there is none.
+func (m *OnceMap[K, V]) Get(ctx context.Context, key K, fn doFunc[V]) (V, error) {
+	if v, ok := m.Load(key); ok {
+		return v, nil
+	}
+
+	v, _, err := m.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		// a previous execution may have completed between Load and Do
+		if v, ok := m.Load(key); ok {
+			return v, nil
+		}
+		v, err := fn(ctx)
+		if err == nil {
+			m.mu.Lock()
+			if m.values == nil {
+				m.values = make(map[K]V)
+			}
+			m.values[key] = v
+			m.mu.Unlock()
+		}
+		return v, err
+	})
+	return v, err
+}
+
+// Load returns the value memoized for key without computing it.
+func (m *OnceMap[K, V]) Load(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Forget removes the value memoized for key, so the next Get computes it again.
+func (m *OnceMap[K, V]) Forget(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, key)
+}
+
+// OnceValueByKey returns a function calling fn at most once per key, like sync.OnceValues
+// keyed by K: the value and error returned by the first call for a key, through singleflight,
+// are returned by every later call for that key. It suits lazy initialization registries
+// (per-tenant clients, per-region connections). Use OnceMap to retry failures instead.
+//
+// fn receives the context of the caller executing it, which is canceled when fn returns,
+// so it must not be retained by the value. Errors returned while that context is done are
+// not memoized, since they come from the caller rather than from fn: the next call retries.
+func OnceValueByKey[K comparable, V any](fn func(ctx context.Context, key K) (V, error)) func(context.Context, K) (V, error) {
+	var m OnceMap[K, Result[V]]
+	return func(ctx context.Context, key K) (V, error) {
+		res, err := m.Get(ctx, key, func(ctx context.Context) (Result[V], error) {
+			v, err := fn(ctx, key)
+			if err != nil && ctx.Err() != nil {
+				return Result[V]{}, err
+			}
+			return Result[V]{Val: v, Err: err}, nil
+		})
+		if err != nil {
+			return res.Val, err
+		}
+		return res.Val, res.Err
+	}
+}
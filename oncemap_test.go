@@ -0,0 +1,92 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOnceMap(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var m OnceMap[string, int]
+	someErr := errors.New("some error")
+
+	calls := 0
+	fn := func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, someErr
+		}
+		return calls, nil
+	}
+
+	if _, err := m.Get(ctx, "key", fn); !errors.Is(err, someErr) {
+		t.Errorf("first Get error = %v; want %v", err, someErr)
+	}
+	for i := 0; i < 3; i++ {
+		if v, err := m.Get(ctx, "key", fn); v != 2 || err != nil {
+			t.Errorf("Get = %v, %v; want memoized 2, nil", v, err)
+		}
+	}
+
+	m.Forget("key")
+	if _, ok := m.Load("key"); ok {
+		t.Error("forgotten key must not be loaded")
+	}
+	if v, _ := m.Get(ctx, "key", fn); v != 3 {
+		t.Errorf("Get after Forget = %d; want 3", v)
+	}
+}
+
+func TestOnceValueByKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+	calls := map[string]int{}
+	get := OnceValueByKey(func(_ context.Context, region string) (string, error) {
+		calls[region]++
+		if region == "bad" {
+			return "", someErr
+		}
+		return "client-" + region, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if v, err := get(ctx, "eu"); v != "client-eu" || err != nil {
+			t.Errorf("get(eu) = %q, %v", v, err)
+		}
+		if _, err := get(ctx, "bad"); !errors.Is(err, someErr) {
+			t.Errorf("get(bad) error = %v; want %v", err, someErr)
+		}
+	}
+	if calls["eu"] != 1 || calls["bad"] != 1 {
+		t.Errorf("calls = %v; want one per key", calls)
+	}
+}
+
+func TestOnceValueByKeyCanceled(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	get := OnceValueByKey(func(ctx context.Context, key string) (int, error) {
+		calls++
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return calls, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := get(ctx, "key"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error with a canceled context = %v; want context.Canceled", err)
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := get(context.Background(), "key"); v != 2 || err != nil {
+			t.Errorf("get = %v, %v; want memoized 2, nil", v, err)
+		}
+	}
+}
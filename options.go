@@ -0,0 +1,128 @@
+package singleflight
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Option configures a Group created by NewGroup.
+type Option[K comparable, V any] func(*options[K, V])
+
+// options holds the optional configuration of a Group.
+// The zero value means "no options", which is the behavior of a zero Group.
+type options[K comparable, V any] struct {
+	stuckAfter   time.Duration
+	onStuck      func(StuckCall[K])
+	onPanic      func(key K, label any, recovered any, stack []byte)
+	leaderStacks bool
+	profile      bool
+	hooks        hookList[K]
+	redact       func(K) string
+	sampler      Sampler
+	lastSize     int
+	lastTTL      time.Duration
+	cooldownBase time.Duration
+	cooldownMax  time.Duration
+	latency      bool
+	latencySize  int
+	latencyClass func(K) K
+	admission    bool
+	pauseQueue   bool
+	quota        int
+	tenantOf     func(ctx context.Context, key K) string
+	tenantLimits map[string]int
+	loadProbes   []LoadProbe
+	abandon      bool
+	attempts     int
+	retryBackoff time.Duration
+	onMutation   func(key K, v V)
+	chaos        *Chaos[K]
+
+	highWatermark int
+	lowWatermark  int
+	onHigh        func(inFlight int)
+	onLow         func(inFlight int)
+
+	unreceivedGrace time.Duration
+	onUnreceived    func(key K, label any, n int)
+
+	recentSize int
+
+	dedupThreshold float64
+	dedupWindow    int
+	onLowDedup     func(ratio float64)
+
+	syncLeader bool
+	timeout    time.Duration
+	name       string
+	strict     bool
+	onMismatch func(*FuncMismatchError)
+	fifo       bool
+	classify   func(K) string
+
+	objective         float64
+	budgetWindow      int
+	onBudgetExhausted func(ErrorBudget)
+
+	cardinalityThreshold float64
+	cardinalityWindow    int
+	onHighCardinality    func(KeyCardinality)
+
+	detach     bool
+	detachKeys map[any]struct{}
+
+	maxSubscribers      int
+	redirectSubscribers bool
+
+	onDuplicateJoin  func(key K, label any, requestID string)
+	priorityShedding bool
+
+	costBudget   int64
+	estimateCost func(K) int64
+}
+
+// NewGroup creates a Group configured with the given options.
+// A zero Group is still valid and behaves like NewGroup called without options.
+func NewGroup[K comparable, V any](opts ...Option[K, V]) *Group[K, V] {
+	g := &Group[K, V]{}
+	o := &options[K, V]{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	g.opts.Store(o)
+	return g
+}
+
+// UpdateOptions atomically applies opts on top of the current options of the group,
+// so a long-lived service can retune it, e.g. from a config watcher, without recreating it.
+// The new options affect the calls made afterwards; calls in flight may observe either.
+// Options appending to a list, like WithHooks, add to the current list.
+// Structures already allocated keep their size, e.g. for WithLastResults or WithLatencyEstimates.
+func (g *Group[K, V]) UpdateOptions(opts ...Option[K, V]) {
+	for {
+		cur := g.opts.Load()
+		o := &options[K, V]{}
+		if cur != nil {
+			*o = *cur
+		}
+		// appending must not write to the arrays shared with cur
+		o.hooks = slices.Clip(o.hooks)
+		o.loadProbes = slices.Clip(o.loadProbes)
+		for _, opt := range opts {
+			opt(o)
+		}
+		if g.opts.CompareAndSwap(cur, o) {
+			return
+		}
+	}
+}
+
+// options returns the current options of the group.
+func (g *Group[K, V]) options() *options[K, V] {
+	if o := g.opts.Load(); o != nil {
+		return o
+	}
+	g.opts.CompareAndSwap(nil, &options[K, V]{})
+	return g.opts.Load()
+}
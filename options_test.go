@@ -0,0 +1,38 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdateOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+	var starts atomic.Int32
+	hooks := Hooks[string]{OnStart: func(string, any) { starts.Add(1) }}
+
+	var g Group[string, int]
+	g.UpdateOptions(WithHooks[string, int](hooks))
+	_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil })
+	if got := starts.Load(); got != 1 {
+		t.Errorf("OnStart called %d times; want 1", got)
+	}
+
+	g.UpdateOptions(WithRetry[string, int](2, time.Millisecond))
+	attempts := 0
+	_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) {
+		attempts++
+		return 0, someErr
+	})
+	if attempts != 2 {
+		t.Errorf("fn executed %d times; want 2 after enabling retries", attempts)
+	}
+	if got := starts.Load(); got != 2 {
+		t.Errorf("OnStart called %d times; want hooks kept across updates", got)
+	}
+}
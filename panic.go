@@ -0,0 +1,56 @@
+package singleflight
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// errGoexit is the error of a call whose function called runtime.Goexit.
+var errGoexit = errors.New("singleflight: runtime.Goexit was called")
+
+// PanicError is the error received by the callers waiting for a call whose function panicked.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack of the goroutine which panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("singleflight: function panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithPanicHandler registers fn to be called with the key, its label (see Hooks), the recovered
+// value and the stack when a function executed by the group panics, before the panic is
+// re-raised, so crash reporters get the key as context.
+func WithPanicHandler[K comparable, V any](fn func(key K, label any, recovered any, stack []byte)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onPanic = fn
+	}
+}
+
+// recoverCall completes the call c for key whose function did not return normally:
+// r is the recovered panic value, or nil if the function called runtime.Goexit.
+// The waiting callers are released and a panic is re-raised.
+func (g *Group[K, V]) recoverCall(c *call[V], key K, r any) {
+	var zero V
+	if r == nil {
+		g.finish(c, key, zero, errGoexit)
+		return
+	}
+
+	stack := debug.Stack()
+	if g.options().onPanic != nil {
+		g.options().onPanic(key, g.keyLabel(key), r, stack)
+	}
+	g.finish(c, key, zero, &PanicError{Value: r, Stack: stack})
+	panic(r)
+}
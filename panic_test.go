@@ -0,0 +1,78 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPanicHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		handledKey string
+		handled    any
+		stack      []byte
+	)
+	g := NewGroup(WithPanicHandler[string, int](func(key string, _ any, recovered any, s []byte) {
+		handledKey, handled, stack = key, recovered, s
+	}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderPanic := make(chan any)
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+		waiter <- err
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if r := <-leaderPanic; r != "boom" {
+		t.Errorf("leader recovered %v; want boom", r)
+	}
+	var perr *PanicError
+	if err := <-waiter; !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("waiter error = %v; want PanicError with boom", err)
+	}
+	if handledKey != "key" || handled != "boom" || !strings.Contains(string(stack), "TestPanicHandler") {
+		t.Errorf("handler got %q, %v, stack %q", handledKey, handled, stack)
+	}
+}
+
+func TestGoexit(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			runtime.Goexit()
+			return 1, nil
+		})
+	}()
+	<-done
+
+	// the key must not stay in flight
+	v, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || err != nil {
+		t.Errorf("Do = %v, %v; want 2, nil", v, err)
+	}
+}
@@ -0,0 +1,107 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPaused is returned by calls to a paused group, see Pause.
+var ErrPaused = errors.New("singleflight: group is paused")
+
+// WithPauseQueueing makes calls to a paused group wait until the group is resumed
+// (or their context is done) instead of failing with ErrPaused.
+func WithPauseQueueing[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.pauseQueue = true
+	}
+}
+
+// Pause stops the group from starting or joining calls, for maintenance of a broken backend:
+// new calls fail with ErrPaused, or wait until Resume with WithPauseQueueing.
+// Calls in flight are not affected.
+func (g *Group[K, V]) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused == nil {
+		g.paused = make(chan struct{})
+	}
+}
+
+// Resume lets a paused group accept calls again and releases the queued calls.
+func (g *Group[K, V]) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.releasePaused()
+}
+
+// Paused reports whether the group is paused.
+func (g *Group[K, V]) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paused != nil
+}
+
+// waitResumed returns ErrPaused if the group is paused, or waits until it is resumed
+// with WithPauseQueueing. It returns the context error if ctx is done first.
+// Must be called with g.mu held, which is released while waiting.
+func (g *Group[K, V]) waitResumed(ctx context.Context) error {
+	for g.paused != nil {
+		if !g.options().pauseQueue {
+			return ErrPaused
+		}
+
+		resumed := g.paused
+		g.mu.Unlock()
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			g.mu.Lock()
+			return ctx.Err()
+		}
+		g.mu.Lock()
+	}
+	return nil
+}
+
+// releasePaused resumes the group if it is paused.
+// Must be called with g.mu held.
+func (g *Group[K, V]) releasePaused() {
+	if g.paused != nil {
+		close(g.paused)
+		g.paused = nil
+	}
+}
+
+// doChanQueued implements DoChanCancel for a group paused with WithPauseQueueing:
+// the call is made in the background when the group is resumed, so DoChan doesn't block.
+func (g *Group[K, V]) doChanQueued(ctx context.Context, key K, fn doFunc[V], resumed <-chan struct{}) (<-chan Result[V], func()) {
+	out := make(chan Result[V], 1)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			out <- Result[V]{Err: ctx.Err()}
+			return
+		case <-stop:
+			return
+		}
+
+		ch, cancel := g.DoChanCancel(ctx, key, fn)
+		select {
+		case res := <-ch:
+			out <- res
+		case <-stop:
+			cancel()
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() { close(stop) })
+	}
+}
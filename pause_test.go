@@ -0,0 +1,93 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	g.Pause()
+	if !g.Paused() {
+		t.Error("group must be paused")
+	}
+	if _, _, err := g.Do(ctx, "key", fn); !errors.Is(err, ErrPaused) {
+		t.Errorf("Do error = %v; want %v", err, ErrPaused)
+	}
+	g.Resume()
+	if v, _, err := g.Do(ctx, "key", fn); v != 1 || err != nil {
+		t.Errorf("Do after Resume = %v, %v; want 1, nil", v, err)
+	}
+}
+
+func TestPauseQueueing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithPauseQueueing[string, int]())
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	g.Pause()
+	ch := g.DoChan(ctx, "key", fn)
+
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := g.Do(shortCtx, "key", fn); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("queued Do error = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case res := <-ch:
+		t.Fatalf("queued call completed while paused: %+v", res)
+	default:
+	}
+	g.Resume()
+	if res := <-ch; res.Val != 1 || res.Err != nil {
+		t.Errorf("queued call = %+v; want 1, nil", res)
+	}
+
+	g.Pause()
+	ch = g.DoChan(ctx, "key", fn)
+	g.Close()
+	if res := <-ch; !errors.Is(res.Err, ErrClosed) {
+		t.Errorf("queued call after Close error = %v; want %v", res.Err, ErrClosed)
+	}
+}
+
+func TestPauseQueueingDoChanRace(t *testing.T) {
+	t.Parallel()
+
+	var (
+		g     *Group[string, int]
+		pause sync.Once
+	)
+	// pause the group between the start of DoChan and the registration of the call
+	g = NewGroup(WithPauseQueueing[string, int](), WithTenantFunc[string, int](func(context.Context, string) string {
+		pause.Do(g.Pause)
+		return ""
+	}))
+
+	returned := make(chan (<-chan Result[int]))
+	go func() {
+		returned <- g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	}()
+	var ch <-chan Result[int]
+	select {
+	case ch = <-returned:
+	case <-time.After(time.Second):
+		g.Resume()
+		t.Fatal("DoChan blocked on a group paused during the call")
+	}
+	g.Resume()
+	if res := <-ch; res.Val != 1 || res.Err != nil {
+		t.Errorf("queued call = %+v; want 1, nil", res)
+	}
+}
@@ -0,0 +1,88 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrShed is matched by the errors received by the waiters shed in favor of callers
+// with a higher priority, see WithPriorityShedding.
+var ErrShed = errors.New("singleflight: waiter shed")
+
+// priorityCtxKey is the context key of the caller priority.
+type priorityCtxKey struct{}
+
+// WithPriority returns a context carrying the priority of the caller, higher is more important,
+// used to shed waiters, see WithPriorityShedding. Callers without priority have priority 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, priority)
+}
+
+// PriorityFromContext returns the priority of the caller set with WithPriority, or 0.
+func PriorityFromContext(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityCtxKey{}).(int)
+	return priority
+}
+
+// WithPriorityShedding makes a caller which would exceed the limit of WithMaxSubscribers
+// shed the waiter with the lowest priority, lower than its own, see WithPriority, instead of
+// being rejected or redirected: the shed waiter receives an error matching ErrShed, and
+// the caller takes its place. Among waiters of the same priority, the latest is shed first.
+// The caller which started the call is never shed.
+func WithPriorityShedding[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.priorityShedding = true
+	}
+}
+
+// recordPriority records the priority of the caller with ctx joining the call c with ch.
+// Must be called with g.mu held.
+func (g *Group[K, V]) recordPriority(ctx context.Context, c *call[V], ch chan<- Result[V]) {
+	if !g.options().priorityShedding || ch == nil {
+		return
+	}
+	if c.priorities == nil {
+		c.priorities = make(map[chan<- Result[V]]int)
+	}
+	c.priorities[ch] = PriorityFromContext(ctx)
+}
+
+// shedVictim returns the channel of the waiter of the call c to shed in favor of a caller
+// with ctx, if any.
+// Must be called with g.mu held.
+func (g *Group[K, V]) shedVictim(ctx context.Context, c *call[V]) (chan<- Result[V], bool) {
+	if !g.options().priorityShedding {
+		return nil, false
+	}
+	priority := PriorityFromContext(ctx)
+	var (
+		victim chan<- Result[V]
+		lowest int
+	)
+	for _, ch := range c.chans {
+		p, ok := c.priorities[ch] // only joined waiters have a priority
+		if !ok || p >= priority {
+			continue
+		}
+		if victim == nil || p <= lowest {
+			victim, lowest = ch, p
+		}
+	}
+	return victim, victim != nil
+}
+
+// shed removes the waiter with the channel ch from the call c for key and sends it an error
+// matching ErrShed.
+// Must be called with g.mu held.
+func (g *Group[K, V]) shed(c *call[V], key K, ch chan<- Result[V]) {
+	if i := slices.Index(c.chans, ch); i >= 0 {
+		c.chans = slices.Delete(c.chans, i, i+1)
+	}
+	c.dups--
+	delete(c.joined, ch)
+	priority := c.priorities[ch]
+	delete(c.priorities, ch)
+	ch <- Result[V]{Err: fmt.Errorf("%w: key %v, priority %d", ErrShed, g.keyLabel(key), priority)}
+}
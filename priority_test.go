@@ -0,0 +1,43 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPriorityShedding(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithMaxSubscribers[string, int](3, false), WithPriorityShedding[string, int]())
+	ctx := context.Background()
+	fn := func(context.Context) (int, error) { return 2, nil }
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(WithPriority(ctx, -1), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	low := g.DoChan(WithPriority(ctx, 1), "key", fn)
+	lowLatest := g.DoChan(WithPriority(ctx, 1), "key", fn)
+
+	// the latest waiter of the lowest priority is shed
+	high := g.DoChan(WithPriority(ctx, 5), "key", fn)
+	if res := <-lowLatest; !errors.Is(res.Err, ErrShed) {
+		t.Errorf("shed result = %+v; want ErrShed", res)
+	}
+	// no waiter has a lower priority than the caller
+	if res := <-g.DoChan(ctx, "key", fn); !errors.Is(res.Err, ErrTooManyWaiters) {
+		t.Errorf("rejected result = %+v; want ErrTooManyWaiters", res)
+	}
+
+	close(release)
+	for _, ch := range []<-chan Result[int]{leader, low, high} {
+		if res := <-ch; res.Val != 1 || res.SharedCount != 3 {
+			t.Errorf("result = %+v; want 1 shared by 3 callers", res)
+		}
+	}
+}
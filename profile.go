@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"runtime/pprof"
+	"sync"
+)
+
+// InflightProfileName is the name of the pprof profile of in-flight calls, see WithInflightProfile.
+const InflightProfileName = "singleflight.inflight"
+
+var (
+	inflightOnce sync.Once
+	inflight     *pprof.Profile
+)
+
+// inflightProfile returns the process-wide profile of in-flight calls, creating it on first use.
+func inflightProfile() *pprof.Profile {
+	inflightOnce.Do(func() {
+		inflight = pprof.Lookup(InflightProfileName)
+		if inflight == nil {
+			inflight = pprof.NewProfile(InflightProfileName)
+		}
+	})
+	return inflight
+}
+
+// WithInflightProfile records every in-flight call of the group in the pprof profile
+// named InflightProfileName, with the stack of the caller which started it, so
+// /debug/pprof/singleflight.inflight reveals stuck keys in production.
+// Recording costs a stack capture per execution.
+func WithInflightProfile[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.profile = true
+	}
+}
+
+// profileCall adds c to the profile of in-flight calls if it is enabled.
+// The stack starts at the internal function which registered the call, registerWait
+// or DoBypass, followed by the exported methods and their callers.
+func (g *Group[K, V]) profileCall(c *call[V]) {
+	if g.options().profile {
+		inflightProfile().Add(c, 3) // Add, profileCall, newCall
+		c.profiled = true
+	}
+}
+
+// unprofileCall removes c from the profile of in-flight calls if it was added,
+// even if the profile was disabled meanwhile by UpdateOptions.
+func (g *Group[K, V]) unprofileCall(c *call[V]) {
+	if c.profiled {
+		inflightProfile().Remove(c)
+	}
+}
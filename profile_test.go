@@ -0,0 +1,64 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestInflightProfile(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithInflightProfile[string, int]())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	p := pprof.Lookup(InflightProfileName)
+	if p == nil || p.Count() != 1 {
+		t.Fatalf("profile %v; want one in-flight call", p)
+	}
+	var buf bytes.Buffer
+	if err := p.WriteTo(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "TestInflightProfile") {
+		t.Errorf("profile does not contain the stack of the caller:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "newCall") || !strings.Contains(buf.String(), "registerWait") {
+		t.Errorf("profile must start at the registration of the call:\n%s", buf.String())
+	}
+
+	close(release)
+	<-done
+	if got := p.Count(); got != 0 {
+		t.Errorf("profile count after the call = %d; want 0", got)
+	}
+
+	// disabling the profile during a call still removes the call
+	started = make(chan struct{})
+	release = make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	g.UpdateOptions(func(o *options[string, int]) { o.profile = false })
+	close(release)
+	<-ch
+	if got := p.Count(); got != 0 {
+		t.Errorf("profile count after a call outliving the profile = %d; want 0", got)
+	}
+}
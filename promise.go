@@ -0,0 +1,114 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// promises holds the pending promises of a group.
+type promises[K comparable, V any] struct {
+	mu      sync.Mutex // serializes registration and completion of promises
+	leaders map[K]*Leader[K, V]
+}
+
+// NewPromise registers a pending call for key, to be completed later with Resolve
+// or Reject by an asynchronous producer (a webhook, a message consumer).
+// Callers of Do, DoChan and Join for key wait for the promise instead of executing.
+// It returns false if a call for key is already in flight.
+func (g *Group[K, V]) NewPromise(key K) (bool, error) {
+	c, isLeader, callCtx, err := g.registerPromise(context.Background(), key, false)
+	defer g.promises.mu.Unlock()
+	if err != nil || !isLeader {
+		return false, err
+	}
+	g.hookStart(c, key)
+	g.storePromise(key, &Leader[K, V]{g: g, c: c, key: key, ctx: callCtx})
+	return true, nil
+}
+
+// Resolve completes the pending promise for key with the value v.
+// It returns false if there is no pending promise for key.
+func (g *Group[K, V]) Resolve(key K, v V) bool {
+	leader := g.takePromise(key)
+	if leader == nil {
+		return false
+	}
+	leader.SetResult(v)
+	return true
+}
+
+// Reject completes the pending promise for key with the error err.
+// It returns false if there is no pending promise for key.
+func (g *Group[K, V]) Reject(key K, err error) bool {
+	leader := g.takePromise(key)
+	if leader == nil {
+		return false
+	}
+	leader.Fail(err)
+	return true
+}
+
+// Join waits for the result of the call in flight for key.
+// If there is none, Join registers a promise for key and waits for it to be resolved,
+// so waits can start before the producer is known.
+// If ctx is done first, Join returns ctx.Err(); the promise stays pending.
+func (g *Group[K, V]) Join(ctx context.Context, key K) (V, error) {
+	c, isLeader, callCtx, err := g.registerPromise(ctx, key, true)
+	if err == nil && isLeader {
+		g.hookStart(c, key)
+		g.storePromise(key, &Leader[K, V]{g: g, c: c, key: key, ctx: callCtx})
+	}
+	g.promises.mu.Unlock()
+
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return (&Follower[V]{c: c}).Wait(ctx)
+}
+
+// registerPromise registers the call of a promise for key, see register, and returns
+// with g.promises.mu held, so the promise is stored before Resolve or Reject can look for it.
+// The lock is released while waiting for a group paused with WithPauseQueueing
+// to be resumed, so the promises of other keys can be completed meanwhile.
+func (g *Group[K, V]) registerPromise(ctx context.Context, key K, join bool) (
+	c *call[V], leader bool, callCtx context.Context, err error,
+) {
+	for {
+		g.promises.mu.Lock()
+		c, leader, callCtx, resumed, err := g.registerWait(ctx, key, nil, nil, join, false)
+		if resumed == nil {
+			return c, leader, callCtx, err
+		}
+		g.promises.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			g.promises.mu.Lock()
+			return nil, false, nil, ctx.Err()
+		}
+	}
+}
+
+// storePromise registers leader as the pending promise for key.
+// Must be called with g.promises.mu held.
+func (g *Group[K, V]) storePromise(key K, leader *Leader[K, V]) {
+	if g.promises.leaders == nil {
+		g.promises.leaders = make(map[K]*Leader[K, V])
+	}
+	g.promises.leaders[key] = leader
+}
+
+// takePromise removes and returns the pending promise for key, if any.
+func (g *Group[K, V]) takePromise(key K) *Leader[K, V] {
+	g.promises.mu.Lock()
+	defer g.promises.mu.Unlock()
+
+	leader, ok := g.promises.leaders[key]
+	if !ok {
+		return nil
+	}
+	delete(g.promises.leaders, key)
+	return leader
+}
@@ -0,0 +1,122 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromise(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g Group[string, string]
+	created, err := g.NewPromise("order:1")
+	if !created || err != nil {
+		t.Fatalf("NewPromise = %v, %v; want true, nil", created, err)
+	}
+	if created, _ = g.NewPromise("order:1"); created {
+		t.Error("NewPromise for a pending key must return false")
+	}
+
+	joined := make(chan string)
+	go func() {
+		v, _ := g.Join(ctx, "order:1")
+		joined <- v
+	}()
+	ch := g.DoChan(ctx, "order:1", func(context.Context) (string, error) {
+		t.Error("function must not be called while a promise is pending")
+		return "", nil
+	})
+
+	for g.Stats().Shared < 2 { // wait for Join
+		time.Sleep(time.Millisecond)
+	}
+	if !g.Resolve("order:1", "paid") {
+		t.Fatal("Resolve must find the pending promise")
+	}
+	if g.Resolve("order:1", "again") {
+		t.Error("Resolve of a completed promise must return false")
+	}
+
+	if v := <-joined; v != "paid" {
+		t.Errorf("Join = %q; want %q", v, "paid")
+	}
+	if res := <-ch; res.Val != "paid" {
+		t.Errorf("DoChan = %q; want %q", res.Val, "paid")
+	}
+}
+
+func TestJoinBeforeProducer(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	someErr := errors.New("some error")
+
+	errCh := make(chan error)
+	go func() {
+		_, err := g.Join(context.Background(), "key")
+		errCh <- err
+	}()
+
+	for !g.Reject("key", someErr) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := <-errCh; !errors.Is(err, someErr) {
+		t.Errorf("Join error = %v; want %v", err, someErr)
+	}
+}
+
+func TestJoinContext(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.Join(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Join error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if !g.Resolve("key", 1) {
+		t.Error("promise must stay pending after Join gives up")
+	}
+}
+
+func TestNewPromisePaused(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithPauseQueueing[string, int]())
+	if created, err := g.NewPromise("b"); !created || err != nil {
+		t.Fatalf("NewPromise = %v, %v; want true, nil", created, err)
+	}
+	g.Pause()
+
+	created := make(chan bool)
+	go func() {
+		ok, _ := g.NewPromise("a")
+		created <- ok
+	}()
+	time.Sleep(10 * time.Millisecond) // let NewPromise wait for Resume
+
+	resolved := make(chan bool)
+	go func() { resolved <- g.Resolve("b", 1) }()
+	select {
+	case ok := <-resolved:
+		if !ok {
+			t.Error("Resolve must find the pending promise")
+		}
+	case <-time.After(time.Second):
+		g.Resume()
+		t.Fatal("Resolve blocked by a NewPromise waiting for Resume")
+	}
+
+	g.Resume()
+	if !<-created {
+		t.Error("NewPromise after Resume must create the promise")
+	}
+	if !g.Resolve("a", 2) {
+		t.Error("Resolve must find the promise created after Resume")
+	}
+}
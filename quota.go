@@ -0,0 +1,36 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrQuotaExceeded is matched by the errors returned when the identity of a caller
+// already leads as many in-flight calls as its quota allows, see WithIdentityQuota.
+var ErrQuotaExceeded = errors.New("singleflight: quota exceeded")
+
+// identityCtxKey is the context key of the caller identity.
+type identityCtxKey struct{}
+
+// WithIdentity returns a context carrying the identity of the caller (a user, a tenant,
+// an API key), used to enforce quotas.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, identity)
+}
+
+// IdentityFromContext returns the identity of the caller set with WithIdentity.
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityCtxKey{}).(string)
+	return identity, ok
+}
+
+// WithIdentityQuota limits the number of distinct in-flight keys a single identity
+// (see WithIdentity) can lead to limit, protecting a shared group from one noisy caller:
+// starting a call beyond the quota fails with an error matching ErrQuotaExceeded.
+// Joining an in-flight call is always allowed. Callers without identity are not limited.
+// With WithTenantFunc, the quota applies to tenants instead of identities.
+func WithIdentityQuota[K comparable, V any](limit int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.quota = limit
+	}
+}
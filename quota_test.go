@@ -0,0 +1,42 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestIdentityQuota(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithIdentityQuota[string, int](2))
+	noisy := WithIdentity(context.Background(), "tenant-a")
+	other := WithIdentity(context.Background(), "tenant-b")
+
+	release := make(chan struct{})
+	slow := func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}
+	a1 := g.DoChan(noisy, "a1", slow)
+	a2 := g.DoChan(noisy, "a2", slow)
+
+	if _, _, err := g.Do(noisy, "a3", slow); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("third key error = %v; want %v", err, ErrQuotaExceeded)
+	}
+	joined := g.DoChan(noisy, "a1", slow) // joining is allowed
+	b1 := g.DoChan(other, "b1", slow)     // other identities are not affected
+	free := g.DoChan(context.Background(), "c1", slow)
+
+	close(release)
+	for _, ch := range []<-chan Result[int]{a1, a2, joined, b1, free} {
+		if res := <-ch; res.Err != nil {
+			t.Errorf("result error = %v", res.Err)
+		}
+	}
+
+	// quota released after completion
+	if _, _, err := g.Do(noisy, "a3", func(context.Context) (int, error) { return 1, nil }); err != nil {
+		t.Errorf("error after completion = %v", err)
+	}
+}
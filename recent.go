@@ -0,0 +1,88 @@
+package singleflight
+
+import "time"
+
+// CompletedCall describes a completed call, see RecentCalls.
+type CompletedCall struct {
+	// Key is the key of the call, redacted if the group has a key redactor.
+	Key any `json:"key"`
+	// Started and Finished are the start and completion times of the execution.
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Dups is the number of callers which shared the result besides the leader.
+	Dups int `json:"dups"`
+	// Err is the error returned by the function, if any.
+	Err error `json:"-"`
+}
+
+// Duration returns the execution time of the call.
+func (cc CompletedCall) Duration() time.Duration {
+	return cc.Finished.Sub(cc.Started)
+}
+
+// recentCalls is a ring buffer of completed calls.
+type recentCalls struct {
+	calls []CompletedCall
+	next  int // index of the next call to overwrite once full
+	size  int // capacity of the ring, the recent size it was filled for
+}
+
+// ordered returns the calls oldest first.
+func (r *recentCalls) ordered() []CompletedCall {
+	calls := make([]CompletedCall, 0, len(r.calls))
+	calls = append(calls, r.calls[r.next:]...)
+	return append(calls, r.calls[:r.next]...)
+}
+
+// resize changes the capacity of the ring to size, keeping the last calls,
+// after the options of the group were updated.
+func (r *recentCalls) resize(size int) {
+	calls := r.ordered()
+	if len(calls) > size {
+		calls = calls[len(calls)-size:]
+	}
+	r.calls, r.next, r.size = calls, 0, size
+}
+
+// WithRecentCalls keeps the last n completed calls, see RecentCalls.
+func WithRecentCalls[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.recentSize = n
+	}
+}
+
+// RecentCalls returns the last completed calls kept by WithRecentCalls, oldest first,
+// to find out after the fact what just hammered a backend.
+func (g *Group[K, V]) RecentCalls() []CompletedCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.recent.ordered()
+}
+
+// recordRecent adds the completed call c for key to the recent calls.
+// Must be called with g.mu held.
+func (g *Group[K, V]) recordRecent(c *call[V], key K, finished time.Time) {
+	size := g.options().recentSize
+	if size <= 0 {
+		return
+	}
+
+	cc := CompletedCall{
+		Key:      g.keyLabel(key),
+		Started:  c.started,
+		Finished: finished,
+		Dups:     c.dups,
+		Err:      c.err,
+	}
+	r := &g.recent
+	if r.size != size {
+		r.resize(size)
+	}
+	if len(r.calls) < size {
+		r.calls = append(r.calls, cc)
+		return
+	}
+	r.calls[r.next] = cc
+	r.next = (r.next + 1) % size
+}
@@ -0,0 +1,63 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestRecentCalls(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithRecentCalls[string, int](2))
+	if calls := g.RecentCalls(); len(calls) != 0 {
+		t.Errorf("RecentCalls = %v; want none", calls)
+	}
+
+	someErr := errors.New("some error")
+	for i := range 3 {
+		_, _, _ = g.Do(context.Background(), strconv.Itoa(i), func(context.Context) (int, error) {
+			return i, someErr
+		})
+	}
+
+	calls := g.RecentCalls()
+	if len(calls) != 2 || calls[0].Key != "1" || calls[1].Key != "2" {
+		t.Fatalf("RecentCalls = %+v; want calls 1 and 2", calls)
+	}
+	if !errors.Is(calls[1].Err, someErr) || calls[1].Duration() < 0 || calls[1].Finished.IsZero() {
+		t.Errorf("call = %+v", calls[1])
+	}
+}
+
+func TestRecentCallsResize(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithRecentCalls[string, int](3))
+	do := func(keys ...int) {
+		for _, i := range keys {
+			_, _, _ = g.Do(context.Background(), strconv.Itoa(i), func(context.Context) (int, error) { return i, nil })
+		}
+	}
+	keys := func() (keys []any) {
+		for _, cc := range g.RecentCalls() {
+			keys = append(keys, cc.Key)
+		}
+		return keys
+	}
+
+	do(0, 1, 2, 3) // wraps the ring
+	g.UpdateOptions(WithRecentCalls[string, int](2))
+	do(4)
+	if got := fmt.Sprint(keys()); got != "[3 4]" {
+		t.Errorf("RecentCalls after shrinking = %s; want [3 4]", got)
+	}
+
+	g.UpdateOptions(WithRecentCalls[string, int](4))
+	do(5, 6, 7)
+	if got := fmt.Sprint(keys()); got != "[4 5 6 7]" {
+		t.Errorf("RecentCalls after growing = %s; want [4 5 6 7]", got)
+	}
+}
@@ -0,0 +1,21 @@
+package singleflight
+
+// WithKeyRedactor sets the function used to render keys on the observability surfaces
+// of the group: CallInfo and DumpState, the errors naming keys and runtime/trace logs.
+// Keys often contain PII or secrets (emails, tokens); a redactor (e.g. a hash or
+// a truncated form) keeps raw keys out of logs, traces and debug pages.
+// Hooks, StuckCall and the callbacks of the options receive the raw key, which they may
+// need, together with its label rendered by the redactor, which is the one to report.
+func WithKeyRedactor[K comparable, V any](redact func(K) string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.redact = redact
+	}
+}
+
+// keyLabel returns key as shown on observability surfaces.
+func (g *Group[K, V]) keyLabel(key K) any {
+	if g.options().redact != nil {
+		return g.options().redact(key)
+	}
+	return key
+}
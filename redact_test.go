@@ -0,0 +1,84 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func redactEmail(key string) string {
+	if i := strings.IndexByte(key, '@'); i > 0 {
+		return key[:1] + "***" + key[i:]
+	}
+	return key
+}
+
+func TestKeyRedactor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(WithKeyRedactor[string, int](redactEmail))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _, _ = g.Do(ctx, "alice@example.com", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	calls := g.Calls()
+	close(release)
+	if len(calls) != 1 || calls[0].Key != "a***@example.com" {
+		t.Errorf("Calls = %+v; want redacted key", calls)
+	}
+
+	_, _, err := g.Do(ctx, "bob@example.com", func(ctx context.Context) (int, error) {
+		v, _, err := g.Do(ctx, "bob@example.com", func(context.Context) (int, error) { return 1, nil })
+		return v, err
+	})
+	var cerr *CycleError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("error = %v; want CycleError", err)
+	}
+	if strings.Contains(cerr.Error(), "bob@") || !strings.Contains(cerr.Error(), "b***@example.com") {
+		t.Errorf("cycle error %q must contain redacted keys only", cerr.Error())
+	}
+}
+
+func TestKeyRedactorSurfaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var labels []any
+	g := NewGroup(
+		WithKeyRedactor[string, int](redactEmail),
+		WithHooks[string, int](Hooks[string]{OnStart: func(_ string, label any) { labels = append(labels, label) }}),
+	)
+
+	gr := NewGraph(g)
+	gr.Add("alice@example.com", []string{"bob@example.com"}, func(context.Context, map[string]int) (int, error) { return 1, nil })
+	gr.Add("bob@example.com", []string{"carol@example.com"}, func(context.Context, map[string]int) (int, error) { return 1, nil })
+	_, err := gr.Do(ctx, "alice@example.com")
+	if !errors.Is(err, ErrUnknownNode) || strings.Contains(err.Error(), "carol@") {
+		t.Errorf("unknown node error %q must contain redacted keys only", err)
+	}
+	gr.Add("carol@example.com", nil, func(context.Context, map[string]int) (int, error) { return 0, errors.New("failed") })
+	_, err = gr.Do(ctx, "alice@example.com")
+	var derr *DependencyError[string]
+	if !errors.As(err, &derr) || strings.Contains(err.Error(), "bob@") || strings.Contains(err.Error(), "carol@") {
+		t.Errorf("dependency error %q must contain redacted keys only", err)
+	}
+	if len(labels) == 0 || labels[0] != "a***@example.com" {
+		t.Errorf("hook labels = %v; want redacted keys", labels)
+	}
+
+	l := NewDataLoader(func(context.Context, []string) (map[string]int, error) { return nil, nil },
+		WithLoaderGroupOptions(WithKeyRedactor[string, int](redactEmail)))
+	if _, err := l.Load(ctx, "dave@example.com"); !errors.Is(err, ErrNoValue) || strings.Contains(err.Error(), "dave@") {
+		t.Errorf("missing value error %q must contain redacted keys only", err)
+	}
+}
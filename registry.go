@@ -0,0 +1,114 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// InitRegistry holds long-lived objects (clients, pools) built lazily, exactly once per key,
+// through singleflight: concurrent first Get calls for a key share one construction.
+// Failed constructions are not retained and are retried by the next Get.
+// Objects are released when evicted and when the registry is closed.
+type InitRegistry[K comparable, V any] struct {
+	init    LoadFunc[K, V]
+	release func(key K, v V) error
+	group   Group[K, V]
+
+	mu     sync.RWMutex
+	values map[K]V
+	closed bool
+}
+
+// NewInitRegistry creates a registry building objects with init and releasing them
+// with release, which may be nil.
+func NewInitRegistry[K comparable, V any](init LoadFunc[K, V], release func(key K, v V) error) *InitRegistry[K, V] {
+	return &InitRegistry[K, V]{
+		init:    init,
+		release: release,
+		values:  make(map[K]V),
+	}
+}
+
+// Get returns the object for key, building it if there is none.
+// The object is built with the values of the context of the first caller, without
+// its cancellation or deadline, so objects keeping the context, e.g. clients running
+// background work, outlive the call; init must bound its own wait if needed.
+// After the registry is closed, Get returns ErrClosed.
+func (r *InitRegistry[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if v, ok, err := r.load(key); ok || err != nil {
+		return v, err
+	}
+
+	v, _, err := r.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		// a previous construction may have completed between load and Do
+		if v, ok, err := r.load(key); ok || err != nil {
+			return v, err
+		}
+		v, err := r.init(context.WithoutCancel(ctx), key)
+		if err != nil {
+			return v, err
+		}
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			var zero V
+			return zero, errors.Join(ErrClosed, r.releaseValue(key, v))
+		}
+		r.values[key] = v
+		r.mu.Unlock()
+		return v, nil
+	})
+	return v, err
+}
+
+// Evict removes the object for key and releases it, so the next Get builds it again,
+// e.g. after a client became unusable.
+func (r *InitRegistry[K, V]) Evict(key K) error {
+	r.mu.Lock()
+	v, ok := r.values[key]
+	delete(r.values, key)
+	r.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return r.releaseValue(key, v)
+}
+
+// Close releases all objects and makes later Get calls fail with ErrClosed.
+// It returns the errors of the releases joined.
+func (r *InitRegistry[K, V]) Close() error {
+	r.mu.Lock()
+	values := r.values
+	r.values = make(map[K]V)
+	r.closed = true
+	r.mu.Unlock()
+
+	var errs []error
+	for key, v := range values {
+		errs = append(errs, r.releaseValue(key, v))
+	}
+	return errors.Join(errs...)
+}
+
+// load returns the object built for key, or ErrClosed if the registry is closed.
+func (r *InitRegistry[K, V]) load(key K) (v V, ok bool, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return v, false, ErrClosed
+	}
+	v, ok = r.values[key]
+	return v, ok, nil
+}
+
+// releaseValue releases the object v built for key.
+func (r *InitRegistry[K, V]) releaseValue(key K, v V) error {
+	if r.release == nil {
+		return nil
+	}
+	return r.release(key, v)
+}
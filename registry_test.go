@@ -0,0 +1,82 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInitRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var (
+		builds   atomic.Int32
+		mu       sync.Mutex
+		released []string
+	)
+	r := NewInitRegistry(func(_ context.Context, key string) (string, error) {
+		return key + "-client-" + string(rune('0'+builds.Add(1))), nil
+	}, func(key string, _ string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		released = append(released, key)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := r.Get(ctx, "db"); v != "db-client-1" || err != nil {
+				t.Errorf("Get = %q, %v; want db-client-1, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := r.Evict("db"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := r.Get(ctx, "db"); v != "db-client-2" {
+		t.Errorf("Get after Evict = %q; want a rebuilt db-client-2", v)
+	}
+	_, _ = r.Get(ctx, "cache")
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Get(ctx, "db"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close error = %v; want %v", err, ErrClosed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(released) != 3 {
+		t.Errorf("released %v; want db twice and cache", released)
+	}
+}
+
+func TestInitRegistryContext(t *testing.T) {
+	t.Parallel()
+
+	type ctxKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "trace"))
+	r := NewInitRegistry(func(ctx context.Context, _ string) (context.Context, error) {
+		return ctx, nil
+	}, nil)
+
+	built, err := r.Get(ctx, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if built.Err() != nil {
+		t.Errorf("context of init canceled after Get: %v", built.Err())
+	}
+	if built.Value(ctxKey{}) != "trace" {
+		t.Error("context of init lost the values of the caller")
+	}
+}
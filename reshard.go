@@ -0,0 +1,93 @@
+package singleflight
+
+import "time"
+
+// lock locks g.mu for a new call, accounting the time spent waiting for it,
+// which measures the contention of the group, see NewAutoSharded.
+func (g *Group[K, V]) lock() {
+	if g.mu.TryLock() {
+		return
+	}
+	start := time.Now()
+	g.mu.Lock()
+	g.lockWait.Add(int64(time.Since(start)))
+}
+
+// handOver closes g and hands its calls in flight and its per-key state over to the
+// groups returned by dst, see Sharded.Resize: callers of the new groups join the calls
+// of g instead of executing again, and the last results, cooldowns and latency estimates
+// of the keys are kept.
+// Must be called with g.mu held.
+func (g *Group[K, V]) handOver(dst func(K) *Group[K, V]) {
+	g.closed = true
+	g.releasePaused() // queued callers get ErrClosed
+
+	for key, c := range g.m {
+		if !c.isDone() {
+			dst(key).adopt(key, c)
+		}
+	}
+	for key, cd := range g.cooldowns {
+		d := dst(key)
+		d.mu.Lock()
+		if d.cooldowns == nil {
+			d.cooldowns = make(map[K]*cooldown)
+		}
+		d.cooldowns[key] = cd
+		d.mu.Unlock()
+	}
+	if g.last != nil {
+		g.last.each(func(ent lruEntry[K, Result[V]]) {
+			d := dst(ent.key)
+			d.mu.Lock()
+			if d.last == nil {
+				d.last = newLRU[K, Result[V]](d.options().lastSize)
+			}
+			d.last.add(ent.key, ent.val, ent.expires)
+			d.mu.Unlock()
+		})
+	}
+	if g.latencies != nil {
+		g.latencies.each(func(ent lruEntry[K, time.Duration]) {
+			d := dst(ent.key)
+			d.mu.Lock()
+			if d.latencies == nil {
+				d.latencies = newLRU[K, time.Duration](d.options().latencySize)
+			}
+			d.latencies.add(ent.key, ent.val, ent.expires)
+			d.mu.Unlock()
+		})
+	}
+}
+
+// adopt registers in g a call for key completing with the call c of another group,
+// unless g already has a call in flight for key. The execution is accounted by the group
+// of c: the proxy call isn't running in g and doesn't call the hooks.
+func (g *Group[K, V]) adopt(key K, c *call[V]) {
+	g.mu.Lock()
+	if _, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		return
+	}
+	if g.m == nil {
+		g.m = make(map[K]*call[V])
+	}
+	proxy := &call[V]{
+		started: c.started,
+		id:      c.id,
+		ctx:     c.ctx,
+		done:    make(chan struct{}),
+		cancel:  func(error) {},
+	}
+	proxy.wg.Add(1)
+	g.generation++
+	proxy.gen = g.generation
+	g.m[key] = proxy
+	g.mu.Unlock()
+
+	go func() {
+		<-c.done
+		proxy.attempts.Store(c.attempts.Load())
+		g.finish(proxy, key, c.val, c.err)
+	}()
+}
@@ -0,0 +1,49 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// attemptCtxKey is the context key of the attempt number of an execution.
+type attemptCtxKey struct{}
+
+// WithRetry re-runs a failing function up to attempts times in total, waiting backoff
+// between attempts, before its error is shared with the callers.
+// Context errors are not retried, nor are attempts made once the call context is done.
+// The function can read its attempt number with Attempt, e.g. to switch to a replica,
+// and the final number of attempts is reported in Result.Attempts.
+func WithRetry[K comparable, V any](attempts int, backoff time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.attempts = attempts
+		o.retryBackoff = backoff
+	}
+}
+
+// Attempt returns the attempt number, starting at 1, of the execution running with ctx,
+// or 0 if ctx is not the context of an execution.
+func Attempt(ctx context.Context) int {
+	n, _ := ctx.Value(attemptCtxKey{}).(int)
+	return n
+}
+
+// retry executes fn for the call c, retrying it according to WithRetry.
+func (g *Group[K, V]) retry(ctx context.Context, c *call[V], fn doFunc[V]) (V, error) {
+	for {
+		attempt := int(c.attempts.Add(1))
+		v, err := fn(context.WithValue(ctx, attemptCtxKey{}, attempt))
+		if err == nil || attempt >= g.options().attempts ||
+			errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return v, err
+		}
+
+		t := time.NewTimer(g.options().retryBackoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return v, err
+		case <-t.C:
+		}
+	}
+}
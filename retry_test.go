@@ -0,0 +1,45 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	someErr := errors.New("some error")
+	g := NewGroup(WithRetry[string, int](3, time.Millisecond))
+
+	var seen []int
+	res := <-g.DoChan(ctx, "key", func(ctx context.Context) (int, error) {
+		seen = append(seen, Attempt(ctx))
+		if len(seen) < 2 {
+			return 0, someErr
+		}
+		return 1, nil
+	})
+	if res.Val != 1 || res.Err != nil || res.Attempts != 2 {
+		t.Errorf("DoChan = %+v; want 1, nil after 2 attempts", res)
+	}
+	if len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Errorf("attempt numbers = %v; want [1 2]", seen)
+	}
+
+	res = <-g.DoChan(ctx, "key", func(context.Context) (int, error) { return 0, someErr })
+	if !errors.Is(res.Err, someErr) || res.Attempts != 3 {
+		t.Errorf("DoChan = %+v; want %v after 3 attempts", res, someErr)
+	}
+
+	res = <-g.DoChan(ctx, "key", func(context.Context) (int, error) { return 0, context.Canceled })
+	if res.Attempts != 1 {
+		t.Errorf("context error retried, attempts = %d", res.Attempts)
+	}
+
+	if n := Attempt(ctx); n != 0 {
+		t.Errorf("Attempt outside an execution = %d; want 0", n)
+	}
+}
@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides whether a call is observed by the hooks of its group.
+// It is called once per execution and must be safe for concurrent use.
+type Sampler func() bool
+
+// WithHookSampling limits the hooks to the calls chosen by s, so instrumentation
+// of very hot groups stays cheap. The decision is taken once per execution:
+// a sampled call reports its start, all its joins and its finish, an unsampled
+// call reports nothing. Stats are not sampled.
+func WithHookSampling[K comparable, V any](s Sampler) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.sampler = s
+	}
+}
+
+// SampleEveryN samples one call in n. n <= 1 samples every call.
+func SampleEveryN(n uint64) Sampler {
+	if n <= 1 {
+		return func() bool { return true }
+	}
+	var count atomic.Uint64
+	return func() bool {
+		return count.Add(1)%n == 1
+	}
+}
+
+// SampleRate samples at most perSecond calls per second, evenly spaced.
+// perSecond <= 0 samples no call.
+func SampleRate(perSecond float64) Sampler {
+	if perSecond <= 0 {
+		return func() bool { return false }
+	}
+	interval := int64(float64(time.Second) / perSecond)
+	var next atomic.Int64 // unix nanoseconds of the next allowed sample
+	return func() bool {
+		now := time.Now().UnixNano()
+		for {
+			n := next.Load()
+			if now < n {
+				return false
+			}
+			if next.CompareAndSwap(n, now+interval) {
+				return true
+			}
+		}
+	}
+}
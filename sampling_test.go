@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHookSampling(t *testing.T) {
+	t.Parallel()
+
+	var starts, finishes atomic.Int32
+	g := NewGroup(
+		WithHooks[string, int](Hooks[string]{
+			OnStart:  func(string, any) { starts.Add(1) },
+			OnFinish: func(string, any, time.Duration, int, error) { finishes.Add(1) },
+		}),
+		WithHookSampling[string, int](SampleEveryN(3)),
+	)
+
+	for i := 0; i < 9; i++ {
+		_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+	}
+	if s, f := starts.Load(), finishes.Load(); s != 3 || f != 3 {
+		t.Errorf("starts, finishes = %d, %d; want 3, 3", s, f)
+	}
+	if got := g.Stats().Executions; got != 9 {
+		t.Errorf("Executions = %d; stats must not be sampled", got)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	t.Parallel()
+
+	s := SampleRate(10)
+	if !s() {
+		t.Error("first call must be sampled")
+	}
+	if s() {
+		t.Error("second immediate call must not be sampled")
+	}
+	time.Sleep(110 * time.Millisecond)
+	if !s() {
+		t.Error("call after the interval must be sampled")
+	}
+}
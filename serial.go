@@ -0,0 +1,55 @@
+package singleflight
+
+import "sync"
+
+// Serial serializes work per key, where Group deduplicates it: tasks submitted
+// for the same key run one at a time in submission order, while tasks for
+// different keys run concurrently. Every task runs, none is shared or skipped.
+// The zero value is ready to use.
+type Serial[K comparable] struct {
+	mu     sync.Mutex
+	queues map[K][]func() // pending tasks of the keys being run; lazily initialized
+	wg     sync.WaitGroup
+}
+
+// Submit queues task for key and returns without waiting for it.
+// A task runs in a goroutine of its key; a panicking task crashes the program.
+func (s *Serial[K]) Submit(key K, task func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wg.Add(1)
+	if s.queues == nil {
+		s.queues = make(map[K][]func())
+	}
+	queue, running := s.queues[key]
+	s.queues[key] = append(queue, task)
+	if !running {
+		go s.run(key)
+	}
+}
+
+// Wait waits until all submitted tasks have run.
+func (s *Serial[K]) Wait() {
+	s.wg.Wait()
+}
+
+// run runs the queued tasks of key until the queue is empty.
+func (s *Serial[K]) run(key K) {
+	for {
+		s.mu.Lock()
+		queue := s.queues[key]
+		if len(queue) == 0 {
+			delete(s.queues, key)
+			s.mu.Unlock()
+			return
+		}
+		task := queue[0]
+		queue[0] = nil
+		s.queues[key] = queue[1:]
+		s.mu.Unlock()
+
+		task()
+		s.wg.Done()
+	}
+}
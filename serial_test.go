@@ -0,0 +1,59 @@
+package singleflight
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSerial(t *testing.T) {
+	t.Parallel()
+
+	var (
+		s       Serial[string]
+		mu      sync.Mutex
+		order   = map[string][]int{}
+		running = map[string]*atomic.Int32{"a": {}, "b": {}}
+		maxConc atomic.Int32
+		total   atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		i := i
+		for _, key := range []string{"a", "b"} {
+			key := key
+			s.Submit(key, func() {
+				if running[key].Add(1) != 1 {
+					t.Errorf("tasks of key %s overlap", key)
+				}
+				if n := total.Add(1); n > maxConc.Load() {
+					maxConc.Store(n)
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				order[key] = append(order[key], i)
+				mu.Unlock()
+				total.Add(-1)
+				running[key].Add(-1)
+			})
+		}
+	}
+	s.Wait()
+
+	for key, got := range order {
+		for i, v := range got {
+			if v != i {
+				t.Fatalf("tasks of key %s ran in order %v; want FIFO", key, got)
+			}
+		}
+	}
+	if maxConc.Load() < 2 {
+		t.Error("tasks of different keys must run concurrently")
+	}
+	time.Sleep(10 * time.Millisecond) // let the key goroutines exit
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queues) != 0 {
+		t.Errorf("queues = %v; want empty", s.queues)
+	}
+}
@@ -0,0 +1,163 @@
+// Package sfdns provides a DNS resolver collapsing concurrent lookups with singleflight.
+package sfdns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// Lookuper is the set of lookup methods of *net.Resolver wrapped by Resolver.
+// Resolver implements it too.
+type Lookuper interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+var _ Lookuper = (*net.Resolver)(nil)
+
+// Resolver wraps a net.Resolver with the same lookup methods. Concurrent lookups
+// of the same name share one query, and results can be cached.
+//
+// net.Resolver does not expose record TTLs, so cached results are kept for the
+// TTL configured with NewResolver. Errors are never cached.
+// Returned slices are copies, callers may modify them.
+type Resolver struct {
+	resolver Lookuper
+
+	host  lookup[[]string]
+	ipa   lookup[[]net.IPAddr]
+	ip    lookup[[]net.IP]
+	netip lookup[[]netip.Addr]
+	addr  lookup[[]string]
+	cname lookup[string]
+	txt   lookup[[]string]
+}
+
+// NewResolver creates a Resolver using r, usually a *net.Resolver,
+// or net.DefaultResolver if r is nil.
+// Results are cached for ttl; a zero ttl disables caching.
+func NewResolver(r Lookuper, ttl time.Duration) *Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return &Resolver{
+		resolver: r,
+		host:     newLookup[[]string](ttl),
+		ipa:      newLookup[[]net.IPAddr](ttl),
+		ip:       newLookup[[]net.IP](ttl),
+		netip:    newLookup[[]netip.Addr](ttl),
+		addr:     newLookup[[]string](ttl),
+		cname:    newLookup[string](ttl),
+		txt:      newLookup[[]string](ttl),
+	}
+}
+
+// LookupHost is like net.Resolver.LookupHost.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, err := r.host.do(ctx, host, func(ctx context.Context) ([]string, error) {
+		return r.resolver.LookupHost(ctx, host)
+	})
+	return clone(addrs), err
+}
+
+// LookupIPAddr is like net.Resolver.LookupIPAddr.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, err := r.ipa.do(ctx, host, func(ctx context.Context) ([]net.IPAddr, error) {
+		return r.resolver.LookupIPAddr(ctx, host)
+	})
+	return cloneIPAddrs(addrs), err
+}
+
+// LookupIP is like net.Resolver.LookupIP.
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	ips, err := r.ip.do(ctx, network+"/"+host, func(ctx context.Context) ([]net.IP, error) {
+		return r.resolver.LookupIP(ctx, network, host)
+	})
+	return cloneIPs(ips), err
+}
+
+// LookupNetIP is like net.Resolver.LookupNetIP.
+func (r *Resolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, err := r.netip.do(ctx, network+"/"+host, func(ctx context.Context) ([]netip.Addr, error) {
+		return r.resolver.LookupNetIP(ctx, network, host)
+	})
+	return clone(addrs), err
+}
+
+// LookupAddr is like net.Resolver.LookupAddr.
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	names, err := r.addr.do(ctx, addr, func(ctx context.Context) ([]string, error) {
+		return r.resolver.LookupAddr(ctx, addr)
+	})
+	return clone(names), err
+}
+
+// LookupCNAME is like net.Resolver.LookupCNAME.
+func (r *Resolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	return r.cname.do(ctx, host, func(ctx context.Context) (string, error) {
+		return r.resolver.LookupCNAME(ctx, host)
+	})
+}
+
+// LookupTXT is like net.Resolver.LookupTXT.
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	txts, err := r.txt.do(ctx, name, func(ctx context.Context) ([]string, error) {
+		return r.resolver.LookupTXT(ctx, name)
+	})
+	return clone(txts), err
+}
+
+// lookup deduplicates and optionally caches one kind of lookup.
+type lookup[V any] struct {
+	group *singleflight.Group[string, V]
+	cache *singleflight.Cache[string, V]
+}
+
+func newLookup[V any](ttl time.Duration) lookup[V] {
+	if ttl > 0 {
+		return lookup[V]{cache: singleflight.NewCache[string, V](ttl)}
+	}
+	return lookup[V]{group: singleflight.NewGroup[string, V]()}
+}
+
+func (l lookup[V]) do(ctx context.Context, key string, fn func(context.Context) (V, error)) (V, error) {
+	if l.cache != nil {
+		return l.cache.Get(ctx, key, fn)
+	}
+	v, _, err := l.group.Do(ctx, key, fn)
+	return v, err
+}
+
+func clone[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// cloneIPs copies the slice and the bytes of every IP, which net.IP shares.
+func cloneIPs(s []net.IP) []net.IP {
+	s = clone(s)
+	for i, ip := range s {
+		s[i] = clone(ip)
+	}
+	return s
+}
+
+// cloneIPAddrs is like cloneIPs for IPAddr.
+func cloneIPAddrs(s []net.IPAddr) []net.IPAddr {
+	s = clone(s)
+	for i := range s {
+		s[i].IP = clone(s[i].IP)
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package sfdns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLookuper answers every lookup with documentation addresses and counts the lookups.
+type fakeLookuper struct {
+	lookups atomic.Int32
+}
+
+func (f *fakeLookuper) lookup() {
+	f.lookups.Add(1)
+	time.Sleep(10 * time.Millisecond)
+}
+
+func (f *fakeLookuper) LookupHost(context.Context, string) ([]string, error) {
+	f.lookup()
+	return []string{"192.0.2.1"}, nil
+}
+
+func (f *fakeLookuper) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	f.lookup()
+	return []net.IPAddr{{IP: net.IPv4(192, 0, 2, 1)}}, nil
+}
+
+func (f *fakeLookuper) LookupIP(context.Context, string, string) ([]net.IP, error) {
+	f.lookup()
+	return []net.IP{net.IPv4(192, 0, 2, 1)}, nil
+}
+
+func (f *fakeLookuper) LookupNetIP(context.Context, string, string) ([]netip.Addr, error) {
+	f.lookup()
+	return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+}
+
+func (f *fakeLookuper) LookupAddr(context.Context, string) ([]string, error) {
+	f.lookup()
+	return []string{"example.com."}, nil
+}
+
+func (f *fakeLookuper) LookupCNAME(context.Context, string) (string, error) {
+	f.lookup()
+	return "example.com.", nil
+}
+
+func (f *fakeLookuper) LookupTXT(context.Context, string) ([]string, error) {
+	f.lookup()
+	return []string{"v=spf1 -all"}, nil
+}
+
+func TestLookupHost(t *testing.T) {
+	t.Parallel()
+
+	var fake fakeLookuper
+	r := NewResolver(&fake, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addrs, err := r.LookupHost(ctx, "example.com")
+			if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+				t.Errorf("LookupHost = %v, %v; want [192.0.2.1]", addrs, err)
+				return
+			}
+			addrs[0] = "modified"
+		}()
+	}
+	wg.Wait()
+
+	addrs, err := r.LookupHost(ctx, "example.com")
+	if err != nil || addrs[0] != "192.0.2.1" {
+		t.Errorf("cached LookupHost = %v, %v; want [192.0.2.1]", addrs, err)
+	}
+	if got := fake.lookups.Load(); got != 1 {
+		t.Errorf("lookups = %d; want 1", got)
+	}
+}
+
+func TestLookupIPCopies(t *testing.T) {
+	t.Parallel()
+
+	var fake fakeLookuper
+	r := NewResolver(&fake, time.Minute)
+	ctx := context.Background()
+	want := net.IPv4(192, 0, 2, 1)
+
+	ips, _ := r.LookupIP(ctx, "ip4", "example.com")
+	ips[0][len(ips[0])-1] = 99
+	addrs, _ := r.LookupIPAddr(ctx, "example.com")
+	addrs[0].IP[len(addrs[0].IP)-1] = 99
+
+	ips, err := r.LookupIP(ctx, "ip4", "example.com")
+	if err != nil || !ips[0].Equal(want) {
+		t.Errorf("cached LookupIP = %v, %v; want [%v]", ips, err, want)
+	}
+	addrs, err = r.LookupIPAddr(ctx, "example.com")
+	if err != nil || !addrs[0].IP.Equal(want) {
+		t.Errorf("cached LookupIPAddr = %v, %v; want [%v]", addrs, err, want)
+	}
+}
+
+func TestLookupNoCache(t *testing.T) {
+	t.Parallel()
+
+	var fake fakeLookuper
+	r := NewResolver(&fake, 0)
+	ctx := context.Background()
+
+	_, _ = r.LookupIP(ctx, "ip4", "example.com")
+	_, _ = r.LookupIP(ctx, "ip4", "example.com")
+	_, _ = r.LookupIP(ctx, "ip6", "example.com")
+	if got := fake.lookups.Load(); got != 3 {
+		t.Errorf("lookups = %d; want 3 without caching", got)
+	}
+
+	var _ Lookuper = r
+}
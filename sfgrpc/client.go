@@ -0,0 +1,46 @@
+package sfgrpc
+
+import (
+	"context"
+
+	"github.com/n-r-w/singleflight/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientInterceptor returns an interceptor sharing the response among concurrent
+// identical calls of idempotent methods. Calls are identical if they have the same
+// method and the same deterministically encoded request. Every caller receives its
+// own copy of the response.
+//
+// The call is made with the context, metadata and call options of the first caller;
+// the call options of the other callers are ignored.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := newConfig(opts)
+	var group singleflight.Group[string, proto.Message]
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption,
+	) error {
+		replyMsg, ok := reply.(proto.Message)
+		if !ok || !cfg.idempotent(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		key, err := callKey(method, req)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		shared, _, err := group.Do(ctx, key, func(ctx context.Context) (proto.Message, error) {
+			resp := replyMsg.ProtoReflect().New().Interface()
+			if err := invoker(ctx, method, req, resp, cc, callOpts...); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		})
+		if err != nil {
+			return err
+		}
+		return copyInto(reply, shared)
+	}
+}
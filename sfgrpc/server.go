@@ -0,0 +1,52 @@
+package sfgrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/n-r-w/singleflight/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor returns an interceptor collapsing concurrent identical requests
+// to idempotent methods into one execution of the handler, so a thundering herd of clients
+// results in one handler execution. Requests are identical if they have the same method and
+// the same deterministically encoded request; metadata is ignored, so methods whose response
+// depends on the caller must not be marked idempotent. Every caller receives its own copy
+// of the response.
+//
+// The handler runs with the context of the first caller.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+	var group singleflight.Group[string, proto.Message]
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !cfg.idempotent(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		key, err := callKey(info.FullMethod, req)
+		if err != nil {
+			return handler(ctx, req)
+		}
+
+		resp, shared, err := group.Do(ctx, key, func(ctx context.Context) (proto.Message, error) {
+			resp, err := handler(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			msg, ok := resp.(proto.Message)
+			if !ok {
+				return nil, fmt.Errorf("sfgrpc: response %T is not a proto message", resp)
+			}
+			return msg, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if shared {
+			return proto.Clone(resp), nil
+		}
+		return resp, nil
+	}
+}
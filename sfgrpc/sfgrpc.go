@@ -0,0 +1,75 @@
+// Package sfgrpc provides gRPC interceptors collapsing concurrent identical calls
+// of idempotent methods with singleflight.
+package sfgrpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Option configures an interceptor.
+type Option func(*config)
+
+type config struct {
+	idempotent func(method string) bool
+}
+
+// WithMethods marks the given full method names ("/package.Service/Method")
+// as idempotent. Calls of other methods are passed through unchanged.
+func WithMethods(methods ...string) Option {
+	set := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		set[m] = struct{}{}
+	}
+	return WithIdempotentFunc(func(method string) bool {
+		_, ok := set[method]
+		return ok
+	})
+}
+
+// WithIdempotentFunc marks the methods for which fn returns true as idempotent.
+func WithIdempotentFunc(fn func(method string) bool) Option {
+	return func(c *config) {
+		c.idempotent = fn
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{idempotent: func(string) bool { return false }}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// callKey derives the key of a call from the method and a deterministic encoding of the request.
+func callKey(method string, req any) (string, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", fmt.Errorf("sfgrpc: request %T is not a proto message", req)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("sfgrpc: marshal request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return method + "/" + hex.EncodeToString(sum[:]), nil
+}
+
+// copyInto replaces the content of dst with a deep copy of src.
+func copyInto(dst, src any) error {
+	dstMsg, ok := dst.(proto.Message)
+	if !ok {
+		return fmt.Errorf("sfgrpc: response %T is not a proto message", dst)
+	}
+	srcMsg, ok := src.(proto.Message)
+	if !ok {
+		return fmt.Errorf("sfgrpc: response %T is not a proto message", src)
+	}
+	proto.Reset(dstMsg)
+	proto.Merge(dstMsg, srcMsg)
+	return nil
+}
@@ -0,0 +1,125 @@
+package sfgrpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// startServer starts a health server counting the calls which reach the handler.
+func startServer(t *testing.T, handled *atomic.Int32, opts ...grpc.ServerOption) *bufconn.Listener {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	counter := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		handled.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return handler(ctx, req)
+	}
+	srv := grpc.NewServer(append(opts, grpc.ChainUnaryInterceptor(counter))...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	return ln
+}
+
+func dial(t *testing.T, ln *bufconn.Listener, opts ...grpc.DialOption) healthpb.HealthClient {
+	t.Helper()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+// callConcurrently makes n concurrent Check calls and returns the responses.
+func callConcurrently(t *testing.T, client healthpb.HealthClient, n int) []*healthpb.HealthCheckResponse {
+	t.Helper()
+
+	type result struct {
+		resp *healthpb.HealthCheckResponse
+		err  error
+	}
+	results := make(chan result, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			results <- result{resp, err}
+		}()
+	}
+
+	resps := make([]*healthpb.HealthCheckResponse, 0, n)
+	for i := 0; i < n; i++ {
+		res := <-results
+		if res.err != nil {
+			t.Fatalf("Check error = %v", res.err)
+		}
+		if res.resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Check status = %v; want SERVING", res.resp.GetStatus())
+		}
+		resps = append(resps, res.resp)
+	}
+	return resps
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var handled atomic.Int32
+	ln := startServer(t, &handled)
+	client := dial(t, ln, grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithMethods(checkMethod))))
+
+	const n = 10
+	resps := callConcurrently(t, client, n)
+	if got := handled.Load(); got < 1 || got >= n {
+		t.Errorf("server handled %d calls; want over 0 and less than %d", got, n)
+	}
+	if resps[0] == resps[1] {
+		t.Error("callers must receive their own copies of the response")
+	}
+}
+
+func TestUnaryClientInterceptorNotIdempotent(t *testing.T) {
+	t.Parallel()
+
+	var handled atomic.Int32
+	ln := startServer(t, &handled)
+	client := dial(t, ln, grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+
+	const n = 5
+	callConcurrently(t, client, n)
+	if got := handled.Load(); got != n {
+		t.Errorf("server handled %d calls; want %d", got, n)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var handled atomic.Int32
+	ln := startServer(t, &handled, grpc.ChainUnaryInterceptor(UnaryServerInterceptor(WithMethods(checkMethod))))
+	client := dial(t, ln)
+
+	const n = 10
+	callConcurrently(t, client, n)
+	if got := handled.Load(); got < 1 || got >= n {
+		t.Errorf("handler executed %d times; want over 0 and less than %d", got, n)
+	}
+}
@@ -0,0 +1,78 @@
+package sfhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// Inspector exposes the state of a group. It is implemented by *singleflight.Group.
+type Inspector interface {
+	Calls() []singleflight.CallInfo
+	Stats() singleflight.Stats
+}
+
+// debugGroup is the state of a group rendered by the debug handler.
+type debugGroup struct {
+	Name  string             `json:"name"`
+	Stats singleflight.Stats `json:"stats"`
+	Calls []debugCall        `json:"calls"`
+}
+
+type debugCall struct {
+	Key       string        `json:"key"`
+	Started   time.Time     `json:"started"`
+	Age       time.Duration `json:"age_ns"`
+	Dups      int           `json:"dups"`
+	Forgotten bool          `json:"forgotten,omitempty"`
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html><head><title>singleflight</title></head><body>
+{{range .}}<h2>{{.Name}}</h2>
+<p>calls {{.Stats.Calls}}, executions {{.Stats.Executions}}, shared {{.Stats.Shared}}, errors {{.Stats.Errors}}, in flight {{.Stats.InFlight}}</p>
+<table border="1"><tr><th>key</th><th>age</th><th>waiters</th><th>forgotten</th></tr>
+{{range .Calls}}<tr><td>{{.Key}}</td><td>{{.Age}}</td><td>{{.Dups}}</td><td>{{.Forgotten}}</td></tr>
+{{end}}</table>
+{{end}}</body></html>
+`))
+
+// DebugHandler returns a handler listing the in-flight keys, waiter counts, call ages
+// and stats of the named groups, for production triage. It is meant to be mounted
+// under /debug/singleflight. The response is HTML, or JSON if the request has
+// the query parameter format=json or accepts application/json.
+// Keys are rendered with fmt; configure groups with sensitive keys with singleflight.WithKeyRedactor.
+func DebugHandler(groups map[string]Inspector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		state := make([]debugGroup, 0, len(groups))
+		for name, g := range groups {
+			dg := debugGroup{Name: name, Stats: g.Stats(), Calls: []debugCall{}}
+			for _, c := range g.Calls() {
+				dg.Calls = append(dg.Calls, debugCall{
+					Key:       fmt.Sprint(c.Key),
+					Started:   c.Started,
+					Age:       c.Age(now),
+					Dups:      c.Dups,
+					Forgotten: c.Forgotten,
+				})
+			}
+			state = append(state, dg)
+		}
+		sort.Slice(state, func(i, j int) bool { return state[i].Name < state[j].Name })
+
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"groups": state})
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = debugTemplate.Execute(w, state)
+	})
+}
@@ -0,0 +1,58 @@
+package sfhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+func TestDebugHandler(t *testing.T) {
+	t.Parallel()
+
+	var g singleflight.Group[string, int]
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go func() {
+		_, _, _ = g.Do(context.Background(), "user:42", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	go func() {
+		_, _, _ = g.Do(context.Background(), "user:42", func(context.Context) (int, error) { return 2, nil })
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	h := DebugHandler(map[string]Inspector{"users": &g})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/singleflight?format=json", nil))
+	var got struct {
+		Groups []debugGroup `json:"groups"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Groups) != 1 || got.Groups[0].Name != "users" || got.Groups[0].Stats.InFlight != 1 {
+		t.Fatalf("groups = %+v", got.Groups)
+	}
+	if calls := got.Groups[0].Calls; len(calls) != 1 || calls[0].Key != "user:42" || calls[0].Dups != 1 {
+		t.Errorf("calls = %+v; want user:42 with 1 waiter", calls)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/singleflight", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "<td>user:42</td>") {
+		t.Errorf("HTML does not list the key:\n%s", body)
+	}
+}
@@ -0,0 +1,181 @@
+// Package sfhttp provides net/http integrations of singleflight.
+package sfhttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// Option configures the middleware or a Transport.
+type Option func(*config)
+
+type config struct {
+	vary      []string
+	keyFunc   func(*http.Request) (string, bool)
+	cacheSize int // see WithCacheSize
+}
+
+// WithVaryHeaders adds request headers whose values are part of the request identity,
+// like the Vary response header (e.g. Accept, Accept-Encoding, Authorization).
+// Requests carrying credentials are only coalesced by the default identity if
+// the credential headers are vary headers, see credentialHeaders.
+func WithVaryHeaders(headers ...string) Option {
+	return func(c *config) {
+		for _, h := range headers {
+			c.vary = append(c.vary, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// WithKeyFunc replaces the derivation of the request identity.
+// fn returns false for requests which must not be coalesced.
+func WithKeyFunc(fn func(*http.Request) (string, bool)) Option {
+	return func(c *config) {
+		c.keyFunc = fn
+	}
+}
+
+// credentialHeaders carry the identity of the client. Requests carrying one of them are
+// not coalesced by the default request identity unless it is a vary header, so that
+// a response is never replayed to another user.
+var credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// unkeyedCredentials reports whether r carries credentials which are not part of its identity.
+func (c *config) unkeyedCredentials(r *http.Request) bool {
+	for _, h := range credentialHeaders {
+		if len(r.Header.Values(h)) > 0 && !slices.Contains(c.vary, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturedResponse is a response recorded from a handler.
+type capturedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// Middleware returns middleware which coalesces concurrent identical safe requests
+// (GET and HEAD with the same method, path, query and vary headers) into one execution
+// of the handler. The response of that execution (status, headers and body) is
+// captured and replayed to all coalesced clients, except its Set-Cookie headers,
+// which only the client whose request was executed receives. Requests carrying
+// credentials (Authorization, Cookie) are not coalesced unless the credential headers
+// are part of the identity, see WithVaryHeaders.
+//
+// The handler runs with the request of the first client. Responses are buffered,
+// so the middleware does not suit streaming handlers.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.keyFunc == nil {
+		cfg.keyFunc = cfg.requestKey
+	}
+
+	var group singleflight.Group[string, *capturedResponse]
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := cfg.keyFunc(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			executed := false
+			resp, _, err := group.Do(r.Context(), key, func(ctx context.Context) (*capturedResponse, error) {
+				executed = true
+				rec := &recorder{header: make(http.Header)}
+				next.ServeHTTP(rec, r.WithContext(ctx))
+				return rec.response(), nil
+			})
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			resp.writeTo(w, executed)
+		})
+	}
+}
+
+// requestKey is the default request identity.
+func (c *config) requestKey(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead || c.unkeyedCredentials(r) {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.Host)
+	b.WriteString(r.URL.Path)
+	b.WriteByte('?')
+	b.WriteString(r.URL.RawQuery)
+	for _, h := range c.vary {
+		b.WriteByte('\n')
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String(), true
+}
+
+// writeTo writes the response to w. The cookies set by the response are only written
+// to the client whose request was executed.
+func (resp *capturedResponse) writeTo(w http.ResponseWriter, executed bool) {
+	header := w.Header()
+	for k, v := range resp.header {
+		if k == "Set-Cookie" && !executed {
+			continue
+		}
+		header[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+// recorder is a http.ResponseWriter capturing the response.
+type recorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+func (r *recorder) response() *capturedResponse {
+	status := r.status
+	if !r.wroteHeader {
+		status = http.StatusOK
+	}
+	return &capturedResponse{
+		status: status,
+		header: r.header.Clone(),
+		body:   r.body.Bytes(),
+	}
+}
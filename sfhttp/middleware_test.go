@@ -0,0 +1,158 @@
+package sfhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingHandler(calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("X-Lang", r.Header.Get("Accept-Language"))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, "hello "+r.URL.Query().Get("name"))
+	})
+}
+
+func get(t *testing.T, url, lang string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Language", lang)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(Middleware(WithVaryHeaders("accept-language"))(newCountingHandler(&calls)))
+	defer srv.Close()
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, body := get(t, srv.URL+"/greet?name=gopher", "en")
+			if resp.StatusCode != http.StatusAccepted || body != "hello gopher" || resp.Header.Get("X-Lang") != "en" {
+				t.Errorf("response = %d %q %v; want 202 \"hello gopher\" with X-Lang en",
+					resp.StatusCode, body, resp.Header)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := calls.Load(); got < 1 || got >= n {
+		t.Errorf("handler called %d times; want over 0 and less than %d", got, n)
+	}
+
+	// a different vary header value is a different request
+	calls.Store(0)
+	if resp, _ := get(t, srv.URL+"/greet?name=gopher", "de"); resp.Header.Get("X-Lang") != "de" {
+		t.Errorf("X-Lang = %q; want de", resp.Header.Get("X-Lang"))
+	}
+	if calls.Load() != 1 {
+		t.Error("request with another vary header value must execute the handler")
+	}
+}
+
+func TestMiddlewareUnsafeMethod(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(Middleware()(newCountingHandler(&calls)))
+	defer srv.Close()
+
+	const n = 5
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(srv.URL, "text/plain", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if got := calls.Load(); got != n {
+		t.Errorf("handler called %d times; want %d", got, n)
+	}
+}
+
+func TestMiddlewareCredentials(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Add("Set-Cookie", "session="+r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, "secret of "+r.Header.Get("Authorization"))
+	})
+	mw := Middleware()(handler)
+
+	serve := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		mw.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// requests of different users are never coalesced
+	var wg sync.WaitGroup
+	for _, auth := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body := serve(auth).Body.String(); body != "secret of "+auth {
+				t.Errorf("%s got %q", auth, body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// anonymous requests are coalesced, but the cookies go to the executed request only
+	calls.Store(0)
+	recs := make(chan *httptest.ResponseRecorder, 3)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs <- serve("")
+		}()
+	}
+	wg.Wait()
+	close(recs)
+	cookies := 0
+	for rec := range recs {
+		if rec.Header().Get("Set-Cookie") != "" {
+			cookies++
+		}
+	}
+	if got := int(calls.Load()); cookies != got {
+		t.Errorf("%d responses set cookies for %d executions; want one per execution", cookies, got)
+	}
+}
@@ -0,0 +1,159 @@
+package sfhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// Transport is a http.RoundTripper revalidating cached responses: successful GET responses
+// with an ETag or Last-Modified header are cached, and later requests are sent as conditional
+// requests, a 304 Not Modified response being replaced with the cached one.
+// Concurrent identical requests are collapsed into one round trip whose response is
+// replayed to all of them, each receiving its own body.
+//
+// The round trip is made with the request of the first caller. Responses are buffered,
+// so the transport does not suit streaming responses.
+type Transport struct {
+	base   http.RoundTripper
+	cfg    *config
+	group  singleflight.Group[string, *validatedResponse]
+	cached *singleflight.Cache[string, *validatedResponse]
+}
+
+// validatedResponse is a buffered response with its validators.
+type validatedResponse struct {
+	capturedResponse
+	etag         string
+	lastModified string
+}
+
+// NewTransport creates a Transport sending requests with base, http.DefaultTransport if nil.
+// WithVaryHeaders and WithKeyFunc configure the identity of requests, WithCacheSize
+// bounds the number of cached responses, DefaultCacheSize by default.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cfg := &config{cacheSize: DefaultCacheSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.keyFunc == nil {
+		cfg.keyFunc = cfg.clientRequestKey
+	}
+
+	return &Transport{
+		base: base,
+		cfg:  cfg,
+		cached: singleflight.NewCache(0,
+			singleflight.WithCacheSize[string, *validatedResponse](cfg.cacheSize)),
+	}
+}
+
+// DefaultCacheSize is the number of responses cached by a Transport without WithCacheSize.
+const DefaultCacheSize = 1000
+
+// WithCacheSize bounds the number of responses cached by a Transport, DefaultCacheSize
+// by default. The least recently used responses are evicted first; 0 means unbounded,
+// for keys known to be few.
+func WithCacheSize(size int) Option {
+	return func(c *config) {
+		c.cacheSize = size
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key, ok := t.cfg.keyFunc(r)
+	if !ok || r.Method != http.MethodGet || r.Body != nil && r.Body != http.NoBody {
+		return t.base.RoundTrip(r)
+	}
+
+	resp, _, err := t.group.Do(r.Context(), key, func(context.Context) (*validatedResponse, error) {
+		return t.revalidate(r, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.toResponse(r), nil
+}
+
+// revalidate makes the round trip for r, conditional if a response is cached for key.
+func (t *Transport) revalidate(r *http.Request, key string) (*validatedResponse, error) {
+	cached, isCached := t.cached.Peek(key)
+	req := r
+	if isCached && r.Header.Get("If-None-Match") == "" && r.Header.Get("If-Modified-Since") == "" {
+		req = r.Clone(r.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && req != r {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return cached, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("sfhttp: read response: %w", err)
+	}
+	v := &validatedResponse{
+		capturedResponse: capturedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body},
+		etag:             resp.Header.Get("ETag"),
+		lastModified:     resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode == http.StatusOK && (v.etag != "" || v.lastModified != "") {
+		t.cached.Set(key, v)
+	}
+	return v, nil
+}
+
+// toResponse returns a response to r with a copy of the buffered response.
+func (v *validatedResponse) toResponse(r *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", v.status, http.StatusText(v.status)),
+		StatusCode:    v.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        v.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(v.body)),
+		ContentLength: int64(len(v.body)),
+		Request:       r,
+	}
+}
+
+// clientRequestKey is the default identity of requests sent by a Transport.
+// Like the default identity of Middleware, it excludes requests with unkeyed credentials.
+func (c *config) clientRequestKey(r *http.Request) (string, bool) {
+	if c.unkeyedCredentials(r) {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.String())
+	for _, h := range c.vary {
+		b.WriteByte('\n')
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String(), true
+}
@@ -0,0 +1,118 @@
+package sfhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, "content")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, WithCacheSize(10))}
+	fetch := func() {
+		resp, err := client.Get(srv.URL + "/doc")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "content" {
+			t.Errorf("response = %d %q; want 200 \"content\"", resp.StatusCode, body)
+		}
+	}
+
+	const n = 10
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fetch()
+			}()
+		}
+		wg.Wait()
+	}
+
+	if got := requests.Load(); got < 2 || got >= 2*n {
+		t.Errorf("server received %d requests; want collapsed requests", got)
+	}
+	if notModified.Load() == 0 {
+		t.Error("cached response must be revalidated")
+	}
+}
+
+func TestTransportCredentials(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("ETag", `"`+r.Header.Get("Authorization")+`"`)
+		_, _ = io.WriteString(w, "secret of "+r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, WithCacheSize(10))}
+	var wg sync.WaitGroup
+	for _, auth := range []string{"alice", "bob", "alice", "bob"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/me", nil)
+			req.Header.Set("Authorization", auth)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); string(body) != "secret of "+auth {
+				t.Errorf("%s got %q", auth, body)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTransportDefaultCacheSize(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+	}))
+	defer srv.Close()
+
+	tr := NewTransport(nil)
+	client := &http.Client{Transport: tr}
+	for i := 0; i < DefaultCacheSize+10; i++ {
+		resp, err := client.Get(srv.URL + "/" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := tr.cached.Len(); n != DefaultCacheSize {
+		t.Errorf("cached %d responses; want %d", n, DefaultCacheSize)
+	}
+}
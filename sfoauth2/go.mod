@@ -0,0 +1,10 @@
+module github.com/n-r-w/singleflight/v2/sfoauth2
+
+go 1.24
+
+require (
+	github.com/n-r-w/singleflight/v2 v2.0.0
+	golang.org/x/oauth2 v0.21.0
+)
+
+replace github.com/n-r-w/singleflight/v2 => ../
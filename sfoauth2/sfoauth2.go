@@ -0,0 +1,114 @@
+// Package sfoauth2 collapses concurrent OAuth2 token refreshes with singleflight.
+package sfoauth2
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+	"golang.org/x/oauth2"
+)
+
+// Refresher caches tokens per credential and collapses concurrent refreshes
+// of the same credential into one call of the underlying token source.
+// Tokens are refreshed early, before they expire, so callers don't receive
+// tokens which expire while a request is in flight.
+//
+// The underlying token sources should fetch a new token on every call: a caching source,
+// like those of oauth2.ReuseTokenSource and oauth2.Config.TokenSource, keeps returning
+// its token until the token is about to expire, defeating the early refresh; wrap the
+// uncached source with oauth2.ReuseTokenSourceWithExpiry and an early expiry of at least
+// early instead. If a source returns the token it returned before, the token is used
+// until it expires and the source is asked again at most every unchangedRetry.
+type Refresher struct {
+	early time.Duration
+	group singleflight.Group[string, *oauth2.Token]
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+// unchangedRetry is the interval between the refreshes of a token
+// which the source returned unchanged, see Refresher.
+const unchangedRetry = time.Second
+
+// cachedToken is the token cached for a credential.
+type cachedToken struct {
+	tok   *oauth2.Token
+	retry time.Time // no refresh before, the source returned the same token
+}
+
+// NewRefresher creates a Refresher which refreshes tokens when they expire within early.
+func NewRefresher(early time.Duration) *Refresher {
+	return &Refresher{
+		early:  early,
+		tokens: make(map[string]cachedToken),
+	}
+}
+
+// NewTokenSource returns a token source for a single credential,
+// see Refresher for the semantics.
+func NewTokenSource(src oauth2.TokenSource, early time.Duration) oauth2.TokenSource {
+	return NewRefresher(early).TokenSource("", src)
+}
+
+// TokenSource returns a token source for the credential identified by key, backed by src.
+// All token sources of the refresher created for the same key share the cached token
+// and its refreshes, so they should be backed by equivalent sources.
+func (r *Refresher) TokenSource(key string, src oauth2.TokenSource) oauth2.TokenSource {
+	return &tokenSource{r: r, key: key, src: src}
+}
+
+// Forget drops the cached token for key, e.g. after it was rejected by a server.
+func (r *Refresher) Forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, key)
+}
+
+func (r *Refresher) token(key string, src oauth2.TokenSource) (*oauth2.Token, error) {
+	r.mu.Lock()
+	cached := r.tokens[key]
+	r.mu.Unlock()
+	if r.fresh(cached.tok) || (cached.tok.Valid() && time.Now().Before(cached.retry)) {
+		return cached.tok, nil
+	}
+
+	tok, _, err := r.group.Do(context.Background(), key, func(context.Context) (*oauth2.Token, error) {
+		tok, err := src.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		entry := cachedToken{tok: tok}
+		if old := r.tokens[key].tok; old != nil && old.AccessToken == tok.AccessToken && !r.fresh(tok) {
+			entry.retry = time.Now().Add(unchangedRetry)
+		}
+		r.tokens[key] = entry
+		return tok, nil
+	})
+	return tok, err
+}
+
+// fresh reports whether tok can be used without a refresh.
+func (r *Refresher) fresh(tok *oauth2.Token) bool {
+	if tok == nil || tok.AccessToken == "" {
+		return false
+	}
+	return tok.Expiry.IsZero() || time.Now().Add(r.early).Before(tok.Expiry)
+}
+
+type tokenSource struct {
+	r   *Refresher
+	key string
+	src oauth2.TokenSource
+}
+
+// Token implements oauth2.TokenSource.
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	return s.r.token(s.key, s.src)
+}
@@ -0,0 +1,133 @@
+package sfoauth2
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type countingSource struct {
+	calls  atomic.Int32
+	expiry time.Duration
+	err    error
+}
+
+func (s *countingSource) Token() (*oauth2.Token, error) {
+	n := s.calls.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &oauth2.Token{
+		AccessToken: "token" + strconv.Itoa(int(n)),
+		Expiry:      time.Now().Add(s.expiry),
+	}, nil
+}
+
+func TestTokenSource(t *testing.T) {
+	t.Parallel()
+
+	src := &countingSource{expiry: time.Hour}
+	ts := NewTokenSource(src, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := ts.Token()
+			if err != nil || tok.AccessToken != "token1" {
+				t.Errorf("Token = %v, %v; want token1", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := ts.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if got := src.calls.Load(); got != 1 {
+		t.Errorf("source called %d times; want 1", got)
+	}
+}
+
+func TestTokenSourceEarlyRefresh(t *testing.T) {
+	t.Parallel()
+
+	// tokens expire within the early refresh window, so every call refreshes
+	src := &countingSource{expiry: 30 * time.Second}
+	ts := NewTokenSource(src, time.Minute)
+
+	tok1, _ := ts.Token()
+	tok2, _ := ts.Token()
+	if tok1.AccessToken == tok2.AccessToken {
+		t.Errorf("token %q must be refreshed before expiry", tok1.AccessToken)
+	}
+}
+
+func TestRefresherKeys(t *testing.T) {
+	t.Parallel()
+
+	r := NewRefresher(time.Minute)
+	someErr := errors.New("some error")
+	good := r.TokenSource("good", &countingSource{expiry: time.Hour})
+	bad := r.TokenSource("bad", &countingSource{err: someErr})
+
+	if _, err := bad.Token(); !errors.Is(err, someErr) {
+		t.Errorf("Token error = %v; want %v", err, someErr)
+	}
+	if tok, err := good.Token(); err != nil || tok.AccessToken != "token1" {
+		t.Errorf("Token = %v, %v; want token1", tok, err)
+	}
+
+	src := &countingSource{expiry: time.Hour}
+	same := r.TokenSource("good", src)
+	if tok, _ := same.Token(); tok.AccessToken != "token1" || src.calls.Load() != 0 {
+		t.Error("token sources of the same key must share the cached token")
+	}
+
+	r.Forget("good")
+	_, _ = same.Token()
+	if src.calls.Load() != 1 {
+		t.Error("Forget must drop the cached token")
+	}
+}
+
+// sameSource returns the same token until it is called after refreshAt, like a caching source.
+type sameSource struct {
+	calls     atomic.Int32
+	refreshAt time.Time
+	tok       *oauth2.Token
+}
+
+func (s *sameSource) Token() (*oauth2.Token, error) {
+	s.calls.Add(1)
+	if time.Now().After(s.refreshAt) {
+		return &oauth2.Token{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}, nil
+	}
+	return s.tok, nil
+}
+
+func TestTokenSourceUnchanged(t *testing.T) {
+	t.Parallel()
+
+	src := &sameSource{
+		refreshAt: time.Now().Add(time.Hour),
+		tok:       &oauth2.Token{AccessToken: "cached", Expiry: time.Now().Add(30 * time.Second)},
+	}
+	ts := NewTokenSource(src, time.Minute)
+
+	for i := 0; i < 10; i++ {
+		if tok, err := ts.Token(); err != nil || tok.AccessToken != "cached" {
+			t.Fatalf("Token = %v, %v; want the cached token", tok, err)
+		}
+	}
+	if got := src.calls.Load(); got != 2 {
+		t.Errorf("source called %d times; want 2, then backing off", got)
+	}
+}
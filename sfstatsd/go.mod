@@ -0,0 +1,7 @@
+module github.com/n-r-w/singleflight/v2/sfstatsd
+
+go 1.24
+
+require github.com/n-r-w/singleflight/v2 v2.0.0
+
+replace github.com/n-r-w/singleflight/v2 => ../
@@ -0,0 +1,124 @@
+// Package sfstatsd reports singleflight metrics to statsd or DogStatsD.
+package sfstatsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// Option configures a Client created by New.
+type Option func(*Client)
+
+// WithPrefix prefixes the names of all metrics, e.g. "myapp.".
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// WithTags adds tags ("name:value") to all metrics.
+// Tags are sent in the DogStatsD format, plain statsd servers ignore them.
+func WithTags(tags ...string) Option {
+	return func(c *Client) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// Client sends metrics over UDP, one datagram per metric.
+// Send errors are ignored: metrics must never fail the application.
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+}
+
+// New creates a client sending metrics to the statsd server at addr ("host:port").
+func New(addr string, opts ...Option) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Count adds n to the counter name.
+func (c *Client) Count(name string, n int64, tags ...string) {
+	c.send(name, strconv.FormatInt(n, 10), "c", tags)
+}
+
+// Timing records the duration d for the timer name, in milliseconds.
+func (c *Client) Timing(name string, d time.Duration, tags ...string) {
+	c.send(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms", tags)
+}
+
+func (c *Client) send(name, value, typ string, tags []string) {
+	var b strings.Builder
+	b.WriteString(c.prefix)
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(typ)
+	if len(c.tags)+len(tags) > 0 {
+		b.WriteString("|#")
+		b.WriteString(strings.Join(append(append([]string(nil), c.tags...), tags...), ","))
+	}
+	_, _ = c.conn.Write([]byte(b.String()))
+}
+
+// Hooks returns singleflight hooks reporting the calls of the group named group
+// to c, tagged with "group:<group>":
+//
+//   - singleflight.started: counter of executions
+//   - singleflight.joined: counter of callers which shared an execution
+//   - singleflight.errors: counter of failed executions
+//   - singleflight.duration: timer of executions
+//   - singleflight.wait: timer of the waits of joined callers for the shared result
+//
+// Keys are not reported, so the cardinality of the metrics stays bounded.
+func Hooks[K comparable](c *Client, group string) singleflight.Hooks[K] {
+	tags := []string{"group:" + group}
+	return hooks(c, func(K) []string { return tags })
+}
+
+// ClassifiedHooks is like Hooks but also tags the metrics with "class:<class>",
+// where classify maps keys to a bounded set of classes, e.g. "user:*",
+// usually the classifier of singleflight.WithMetricsKeyClassifier.
+func ClassifiedHooks[K comparable](c *Client, group string, classify func(K) string) singleflight.Hooks[K] {
+	tag := "group:" + group
+	return hooks(c, func(key K) []string { return []string{tag, "class:" + classify(key)} })
+}
+
+// hooks returns the hooks of Hooks and ClassifiedHooks, tagging the metrics of key with tags(key).
+func hooks[K comparable](c *Client, tags func(K) []string) singleflight.Hooks[K] {
+	return singleflight.Hooks[K]{
+		OnStart: func(key K, _ any) {
+			c.Count("singleflight.started", 1, tags(key)...)
+		},
+		OnJoin: func(key K, _ any) {
+			c.Count("singleflight.joined", 1, tags(key)...)
+		},
+		OnFinish: func(key K, _ any, d time.Duration, _ int, err error) {
+			if err != nil {
+				c.Count("singleflight.errors", 1, tags(key)...)
+			}
+			c.Timing("singleflight.duration", d, tags(key)...)
+		},
+		OnWait: func(key K, _ any, d time.Duration, _ error) {
+			c.Timing("singleflight.wait", d, tags(key)...)
+		},
+	}
+}
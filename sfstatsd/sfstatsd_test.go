@@ -0,0 +1,102 @@
+package sfstatsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n-r-w/singleflight/v2"
+)
+
+// listen starts a UDP server and returns its address and a function receiving n datagrams.
+func listen(t *testing.T) (string, func(n int) []string) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+
+	return pc.LocalAddr().String(), func(n int) []string {
+		var got []string
+		buf := make([]byte, 1024)
+		for i := 0; i < n; i++ {
+			_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+			m, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			got = append(got, string(buf[:m]))
+		}
+		sort.Strings(got)
+		return got
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	addr, receive := listen(t)
+	c, err := New(addr, WithPrefix("app."), WithTags("env:test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Count("hits", 2, "a:b")
+	c.Timing("latency", 1500*time.Microsecond)
+
+	want := []string{"app.hits:2|c|#env:test,a:b", "app.latency:1.5|ms|#env:test"}
+	if got := receive(2); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	addr, receive := listen(t)
+	c, err := New(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	g := singleflight.NewGroup(singleflight.WithHooks[string, int](Hooks[string](c, "users")))
+	_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) {
+		return 0, errors.New("some error")
+	})
+
+	got := receive(3)
+	if !strings.HasPrefix(got[0], "singleflight.duration:") || !strings.HasSuffix(got[0], "|ms|#group:users") {
+		t.Errorf("timing = %q", got[0])
+	}
+	if got[1] != "singleflight.errors:1|c|#group:users" || got[2] != "singleflight.started:1|c|#group:users" {
+		t.Errorf("counters = %q", got[1:])
+	}
+}
+
+func TestClassifiedHooks(t *testing.T) {
+	t.Parallel()
+
+	addr, receive := listen(t)
+	c, err := New(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	classify := func(key string) string { return strings.SplitN(key, ":", 2)[0] + ":*" }
+	g := singleflight.NewGroup(singleflight.WithHooks[string, int](ClassifiedHooks(c, "users", classify)))
+	_, _, _ = g.Do(context.Background(), "user:1", func(context.Context) (int, error) { return 1, nil })
+
+	got := receive(2)
+	if !strings.HasSuffix(got[0], "|ms|#group:users,class:user:*") || got[1] != "singleflight.started:1|c|#group:users,class:user:*" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+)
+
+// Sharded spreads keys over several groups, so hot groups don't contend on a single mutex.
+// Keys are assigned to shards with hash/maphash.Comparable, which works for any comparable
+// K without a user hash function. Calls for the same key always use the same shard,
+// so duplicate suppression is unaffected, including across Resize.
+type Sharded[K comparable, V any] struct {
+	seed   maphash.Seed
+	opts   []Option[K, V]
+	shards atomic.Pointer[[]*Group[K, V]]
+
+	// serializes Resize, Close, Pause, Resume and SetEnabled,
+	// protects retired, retiredStats, retiredWait, closed, paused and disabled
+	mu           sync.Mutex
+	retired      []*Group[K, V]
+	retiredStats Stats
+	retiredWait  int64 // lock wait of the retired shards, see lockWait
+	closed       bool
+	paused       bool
+	disabled     bool
+
+	stop     chan struct{} // stops the tuner, see NewAutoSharded; nil without tuner
+	stopOnce sync.Once
+}
+
+// NewSharded creates a Sharded with n shards (at least 1), all configured with opts.
+func NewSharded[K comparable, V any](n int, opts ...Option[K, V]) *Sharded[K, V] {
+	s := &Sharded[K, V]{
+		seed: maphash.MakeSeed(),
+		opts: opts,
+	}
+	s.shards.Store(s.newShards(n))
+	return s
+}
+
+// newShards creates n shards, at least 1, paused and enabled like s.
+// Must be called with s.mu held, or before s is shared.
+func (s *Sharded[K, V]) newShards(n int) *[]*Group[K, V] {
+	shards := make([]*Group[K, V], max(n, 1))
+	for i := range shards {
+		shards[i] = NewGroup(s.opts...)
+		if s.paused {
+			shards[i].Pause()
+		}
+		shards[i].SetEnabled(!s.disabled)
+	}
+	return &shards
+}
+
+// Shard returns the group handling key.
+// A shard replaced by Resize rejects new calls with ErrClosed.
+func (s *Sharded[K, V]) Shard(key K) *Group[K, V] {
+	return s.shardIn(*s.shards.Load(), key)
+}
+
+// shardIn returns the group of shards handling key.
+func (s *Sharded[K, V]) shardIn(shards []*Group[K, V], key K) *Group[K, V] {
+	return shards[maphash.Comparable(s.seed, key)%uint64(len(shards))]
+}
+
+// Len returns the number of shards.
+func (s *Sharded[K, V]) Len() int {
+	return len(*s.shards.Load())
+}
+
+// Resize replaces the shards with n new shards (at least 1). The calls in flight on the old
+// shards are handed over to the new shards, so calls made meanwhile for the same keys join
+// them, and so are the last results, cooldowns and latency estimates of the keys.
+// The old shards are closed: calls made through Sharded are retried on the new shards,
+// but calls made directly on an old shard obtained with Shard fail with ErrClosed, and so do
+// the results of DoChan calls queued by a paused shard, see WithPauseQueueing.
+// State set on individual shards, like Pause or SetEnabled, is not carried over:
+// use the methods of Sharded. Resize has no effect after Close.
+func (s *Sharded[K, V]) Resize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	old := *s.shards.Load()
+	next := *s.newShards(n)
+	for _, g := range old {
+		g.mu.Lock()
+	}
+	for _, g := range old {
+		g.handOver(func(key K) *Group[K, V] { return s.shardIn(next, key) })
+		s.retiredWait += g.lockWait.Load()
+	}
+	s.shards.Store(&next)
+	for _, g := range old {
+		g.mu.Unlock()
+	}
+	s.retired = append(s.retired, old...)
+	s.foldRetired()
+}
+
+// lockWait returns the total time new calls waited for the locks of the shards,
+// including the retired ones, in nanoseconds.
+func (s *Sharded[K, V]) lockWait() int64 {
+	s.mu.Lock()
+	total := s.retiredWait
+	shards := *s.shards.Load()
+	s.mu.Unlock()
+
+	for _, g := range shards {
+		total += g.lockWait.Load()
+	}
+	return total
+}
+
+// retry reports whether a call rejected with err by one of shards must be retried,
+// because shards were replaced by Resize meanwhile.
+func (s *Sharded[K, V]) retry(shards *[]*Group[K, V], err error) bool {
+	return errors.Is(err, ErrClosed) && s.shards.Load() != shards
+}
+
+// foldRetired adds the stats of the retired shards without calls in flight to retiredStats
+// and drops them.
+// Must be called with s.mu held.
+func (s *Sharded[K, V]) foldRetired() {
+	running := s.retired[:0]
+	for _, g := range s.retired {
+		st := g.Stats()
+		if st.InFlight > 0 {
+			running = append(running, g)
+			continue
+		}
+		s.retiredStats = s.retiredStats.Add(st)
+	}
+	clear(s.retired[len(running):])
+	s.retired = running
+}
+
+// Do is Group.Do on the shard of key.
+func (s *Sharded[K, V]) Do(ctx context.Context, key K, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	for {
+		shards := s.shards.Load()
+		v, shared, err = s.shardIn(*shards, key).Do(ctx, key, fn)
+		if !s.retry(shards, err) {
+			return v, shared, err
+		}
+	}
+}
+
+// DoChan is Group.DoChan on the shard of key.
+func (s *Sharded[K, V]) DoChan(ctx context.Context, key K, fn doFunc[V]) <-chan Result[V] {
+	for {
+		shards := s.shards.Load()
+		ch, _, err := s.shardIn(*shards, key).doChanCancel(ctx, key, fn)
+		if err == nil {
+			return ch
+		}
+		if !s.retry(shards, err) {
+			rejected := make(chan Result[V], 1)
+			rejected <- Result[V]{Err: err}
+			return rejected
+		}
+	}
+}
+
+// ForgetUnshared is Group.ForgetUnshared on the shard of key.
+func (s *Sharded[K, V]) ForgetUnshared(key K) bool {
+	return s.Shard(key).ForgetUnshared(key)
+}
+
+// Stats returns the sum of the stats of all shards, including the shards replaced by Resize.
+func (s *Sharded[K, V]) Stats() Stats {
+	s.mu.Lock()
+	s.foldRetired()
+	total := s.retiredStats
+	for _, g := range s.retired {
+		total = total.Add(g.Stats())
+	}
+	s.mu.Unlock()
+
+	for _, g := range *s.shards.Load() {
+		total = total.Add(g.Stats())
+	}
+	return total
+}
+
+// Pause is Group.Pause on all shards, including the shards created later by Resize.
+func (s *Sharded[K, V]) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = true
+	for _, g := range *s.shards.Load() {
+		g.Pause()
+	}
+}
+
+// Resume is Group.Resume on all shards.
+func (s *Sharded[K, V]) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = false
+	for _, g := range *s.shards.Load() {
+		g.Resume()
+	}
+}
+
+// SetEnabled is Group.SetEnabled on all shards, including the shards created later by Resize.
+func (s *Sharded[K, V]) SetEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disabled = !enabled
+	for _, g := range *s.shards.Load() {
+		g.SetEnabled(enabled)
+	}
+}
+
+// Close closes all shards and stops the tuner of NewAutoSharded.
+func (s *Sharded[K, V]) Close() {
+	if s.stop != nil {
+		s.stopOnce.Do(func() { close(s.stop) })
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for _, g := range *s.shards.Load() {
+		g.Close()
+	}
+}
@@ -0,0 +1,160 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharded(t *testing.T) {
+	t.Parallel()
+
+	type key struct {
+		tenant string
+		id     int
+	}
+	s := NewSharded[key, int](8)
+
+	if s.Shard(key{"a", 1}) != s.Shard(key{"a", 1}) {
+		t.Fatal("a key must always map to the same shard")
+	}
+	used := map[*Group[key, int]]bool{}
+	for i := 0; i < 100; i++ {
+		used[s.Shard(key{"a", i})] = true
+	}
+	if len(used) < 2 {
+		t.Error("keys must be spread over shards")
+	}
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, _ := s.Do(context.Background(), key{"a", 1}, func(context.Context) (int, error) {
+				<-release
+				return 1, nil
+			})
+			if v != 1 {
+				t.Errorf("Do = %d; want 1", v)
+			}
+		}()
+	}
+	for s.Stats().Calls < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if st := s.Stats(); st.Executions != 1 || st.Shared != 4 {
+		t.Errorf("Stats = %+v; want 1 execution shared by 4", st)
+	}
+}
+
+func TestShardedResize(t *testing.T) {
+	t.Parallel()
+
+	s := NewSharded[string, int](2)
+	release := make(chan struct{})
+	ch := s.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	s.Resize(4)
+	if s.Len() != 4 {
+		t.Errorf("Len = %d; want 4", s.Len())
+	}
+	_, _, _ = s.Do(context.Background(), "other", func(context.Context) (int, error) { return 2, nil })
+	close(release)
+	if res := <-ch; res.Val != 1 {
+		t.Errorf("call in flight during Resize = %+v; want 1", res)
+	}
+	if st := s.Stats(); st.Calls != 2 || st.InFlight != 0 {
+		t.Errorf("Stats = %+v; want 2 calls including the retired shards", st)
+	}
+}
+
+func TestShardedResizeKeepsCalls(t *testing.T) {
+	t.Parallel()
+
+	s := NewSharded(1, WithLastResults[string, int](10, 0))
+	_, _, _ = s.Do(context.Background(), "done", func(context.Context) (int, error) { return 3, nil })
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := s.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	old := s.Shard("key")
+	s.Resize(8)
+	if _, _, err := old.Do(context.Background(), "key", func(context.Context) (int, error) { return 0, nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Do on a retired shard = %v; want ErrClosed", err)
+	}
+	if res, ok := s.Shard("done").LastResult("done"); !ok || res.Val != 3 {
+		t.Errorf("LastResult after Resize = %+v, %v; want 3", res, ok)
+	}
+
+	joined := s.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	close(release)
+	if res := <-ch; res.Val != 1 {
+		t.Errorf("call in flight during Resize = %+v; want 1", res)
+	}
+	if res := <-joined; res.Val != 1 || !res.Shared {
+		t.Errorf("call made after Resize = %+v; want the shared result 1", res)
+	}
+}
+
+func TestShardedPause(t *testing.T) {
+	t.Parallel()
+
+	s := NewSharded[string, int](1)
+	s.Pause()
+	s.Resize(2)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if _, _, err := s.Do(context.Background(), key, func(context.Context) (int, error) { return 1, nil }); !errors.Is(err, ErrPaused) {
+			t.Errorf("Do on a paused shard = %v; want ErrPaused", err)
+		}
+	}
+	s.Resume()
+	if v, _, err := s.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do after Resume = %v, %v; want 1, nil", v, err)
+	}
+}
+
+func TestAutoSharded(t *testing.T) {
+	t.Parallel()
+
+	cfg := AutoShard{Min: 2, Max: 8, Grow: 0.1, Shrink: 0.001}
+	for _, tc := range []struct {
+		n          int
+		contention float64
+		want       int
+	}{
+		{2, 0.5, 4},
+		{8, 0.5, 8},
+		{4, 0.01, 4},
+		{4, 0, 2},
+		{2, 0, 2},
+	} {
+		if got := tunedShards(cfg, tc.n, tc.contention); got != tc.want {
+			t.Errorf("tunedShards(%d, %v) = %d; want %d", tc.n, tc.contention, got, tc.want)
+		}
+	}
+
+	s := NewAutoSharded[string, int](AutoShard{Min: 2, Max: 4, Interval: time.Millisecond})
+	defer s.Close()
+	if v, _, err := s.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do = %v, %v; want 1, nil", v, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n := s.Len(); n < 2 || n > 4 {
+		t.Errorf("Len = %d; want within [2, 4]", n)
+	}
+}
@@ -0,0 +1,55 @@
+package singleflight
+
+import (
+	"errors"
+	"runtime"
+	"runtime/metrics"
+)
+
+// ErrOverloaded is returned instead of starting an execution while the process is overloaded,
+// see WithLoadShedding.
+var ErrOverloaded = errors.New("singleflight: overloaded")
+
+// LoadProbe reports whether the process is overloaded. It is called for every call
+// which may start an execution, before the group is locked so slow probes don't
+// serialize the callers, and must be cheap and safe for concurrent use.
+type LoadProbe func() bool
+
+// WithLoadShedding makes the group shed new executions with ErrOverloaded while any of
+// the probes reports an overload. Callers joining an in-flight call are still accepted,
+// since they add no load.
+func WithLoadShedding[K comparable, V any](probes ...LoadProbe) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.loadProbes = append(o.loadProbes, probes...)
+	}
+}
+
+// MaxGoroutines returns a probe reporting an overload when the process runs more than n goroutines.
+func MaxGoroutines(n int) LoadProbe {
+	return func() bool {
+		return runtime.NumGoroutine() > n
+	}
+}
+
+// heapMetric is the runtime metric of the memory occupied by live and unswept heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// MaxHeap returns a probe reporting an overload when heap objects occupy more than n bytes.
+func MaxHeap(n uint64) LoadProbe {
+	return func() bool {
+		sample := []metrics.Sample{{Name: heapMetric}}
+		metrics.Read(sample)
+		return sample[0].Value.Kind() == metrics.KindUint64 && sample[0].Value.Uint64() > n
+	}
+}
+
+// checkLoad returns ErrOverloaded if a load probe reports an overload.
+// It is called without g.mu held.
+func (g *Group[K, V]) checkLoad() error {
+	for _, probe := range g.options().loadProbes {
+		if probe() {
+			return ErrOverloaded
+		}
+	}
+	return nil
+}
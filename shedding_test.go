@@ -0,0 +1,78 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedding(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var overloaded atomic.Bool
+	g := NewGroup(WithLoadShedding[string, int](func() bool { return overloaded.Load() }))
+
+	release := make(chan struct{})
+	running := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	overloaded.Store(true)
+	if _, _, err := g.Do(ctx, "other", func(context.Context) (int, error) { return 2, nil }); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("new execution error = %v; want %v", err, ErrOverloaded)
+	}
+	joined := g.DoChan(ctx, "key", func(context.Context) (int, error) { return 3, nil })
+	close(release)
+	if res := <-joined; res.Val != 1 || res.Err != nil {
+		t.Errorf("joined = %+v; joins must be accepted while overloaded", res)
+	}
+	<-running
+
+	overloaded.Store(false)
+	if _, _, err := g.Do(ctx, "other", func(context.Context) (int, error) { return 2, nil }); err != nil {
+		t.Errorf("error after the overload = %v", err)
+	}
+}
+
+func TestLoadProbes(t *testing.T) {
+	t.Parallel()
+
+	if MaxGoroutines(1 << 30)() {
+		t.Error("MaxGoroutines must not report an overload below the limit")
+	}
+	if !MaxGoroutines(0)() {
+		t.Error("MaxGoroutines must report an overload above the limit")
+	}
+	if MaxHeap(1 << 50)() {
+		t.Error("MaxHeap must not report an overload below the limit")
+	}
+	if !MaxHeap(1)() {
+		t.Error("MaxHeap must report an overload above the limit")
+	}
+}
+
+func TestLoadProbeUnlocked(t *testing.T) {
+	t.Parallel()
+
+	var g *Group[string, int]
+	// a probe using the group deadlocks if it runs under the lock of the group
+	g = NewGroup(WithLoadShedding[string, int](func() bool { return g.Paused() }))
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil })
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Do error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("load probe called with the group locked")
+	}
+}
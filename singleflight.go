@@ -11,11 +11,22 @@ package singleflight
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrClosed is returned for calls made after the group was closed.
+	ErrClosed = errors.New("singleflight: group is closed")
+	// ErrDrained is matched by the cancellation cause of leader contexts aborted by Drain.
+	ErrDrained = errors.New("singleflight: call aborted by drain")
 )
 
 // doFunc is the function to be executed by Do and DoChan.
-type doFunc[V any] func(context.Context) (V, error)
+// It is an alias, so other packages can implement Doer by spelling out the function type.
+type doFunc[V any] = func(context.Context) (V, error)
 
 // call is an in-flight or completed singleflight.Do call
 type call[V any] struct {
@@ -23,21 +34,98 @@ type call[V any] struct {
 
 	// These fields are written once before the WaitGroup is done
 	// and are only read after the WaitGroup is done.
-	val V
-	err error
+	val      V
+	err      error
+	finished time.Time
+	// onShare, if set by the leader, is called with the mutex held and
+	// the final number of duplicates before the waiters are released.
+	onShare func(dups int)
 
 	// These fields are read and written with the singleflight
 	// mutex held before the WaitGroup is done, and are read but
 	// not written after the WaitGroup is done.
-	dups  int
-	chans []chan<- Result[V]
+	dups       int
+	chans      []chan<- Result[V]
+	callbacks  []func(Result[V])              // see DoCallback
+	priorities map[chan<- Result[V]]int       // priorities of the channels of duplicates, see WithPriorityShedding; lazily initialized
+	requests   map[string]struct{}            // IDs of the requests of the callers, see WithDuplicateJoinDetection; lazily initialized
+	leaderGone bool                           // the caller which started the call stopped waiting, see DoChanCancel
+	stops      []func() bool                  // unregister the context.AfterFunc of the callers
+	joined     map[chan<- Result[V]]time.Time // join time of the channels of duplicates; lazily initialized
+	fnPC       uintptr                        // code of the function of the call, see WithStrictFuncs
+
+	attempts atomic.Int32 // executions of the function so far, see WithRetry
+
+	// These fields are set when the call is created and never change.
+	started    time.Time
+	id         uint64 // see CallID
+	gen        uint64 // see Generation
+	sampled    bool   // hooks are called for the call, see WithHookSampling
+	class      string // class of the key, see WithMetricsKeyClassifier
+	classified bool
+	tenant     string          // tenant of the leader holding a quota, see WithIdentityQuota and WithTenantFunc
+	ctx        context.Context // context of the execution
+	stack      []byte
+	profiled   bool // see WithInflightProfile
+	watchdog   *time.Timer
+	cancel     context.CancelCauseFunc
+	done       chan struct{} // closed when the call is complete
+}
+
+// result returns the result of the completed call.
+func (c *call[V]) result() Result[V] {
+	return Result[V]{
+		Val:         c.val,
+		Err:         c.err,
+		Shared:      c.dups > 0,
+		SharedCount: c.dups + 1,
+		Attempts:    int(c.attempts.Load()),
+		CallID:      c.id,
+		Started:     c.started,
+		Finished:    c.finished,
+	}
+}
+
+// isDone reports whether the call is complete.
+func (c *call[V]) isDone() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
 }
 
 // Group represents a class of work and forms a namespace in
 // which units of work can be executed with duplicate suppression.
 type Group[K comparable, V any] struct {
-	mu sync.Mutex     // protects m
-	m  map[K]*call[V] // lazily initialized
+	mu         sync.Mutex     // protects m, running, closed, paused, aboveHigh and generation
+	m          map[K]*call[V] // lazily initialized
+	running    map[*call[V]]K // all calls in progress, including forgotten ones; lazily initialized
+	closed     bool
+	paused     chan struct{} // closed on Resume, nil when not paused
+	aboveHigh  bool          // the high watermark was reached, see WithHighWatermark
+	generation uint64        // generation of the last execution, see Generation
+	disabled   atomic.Bool   // see SetEnabled
+	lockWait   atomic.Int64  // nanoseconds new calls waited for mu, see NewAutoSharded
+
+	opts  atomic.Pointer[options[K, V]] // nil means no options, see UpdateOptions
+	stats groupStats
+	last  *lru[K, Result[V]] // retained results, protected by mu; lazily initialized
+
+	cooldowns map[K]*cooldown         // keys failing consecutively, protected by mu; lazily initialized
+	swept     time.Time               // last sweep of cooldowns, see sweepCooldowns, protected by mu
+	latencies *lru[K, time.Duration]  // execution time estimates, protected by mu; lazily initialized
+	tenants   map[string]*tenantState // per tenant state, protected by mu; lazily initialized
+	recent    recentCalls             // see WithRecentCalls, protected by mu
+	classes   map[string]*Stats       // per class stats, see WithMetricsKeyClassifier, protected by mu; lazily initialized
+	dedup     dedupWindow             // see WithDedupRatioAlert, protected by mu
+	distinct  cardinalityWindow       // see WithKeyCardinalityAlert, protected by mu
+	budget    budgetWindow            // see WithErrorBudget, protected by mu
+	cost      costBudget              // see WithCostBudget
+	shared    map[K]sharedValue[V]    // last shared results, see WithMutationDetection; protected by mu; lazily initialized
+
+	promises promises[K, V]
 }
 
 // Result holds the results of Do, so they can be passed
@@ -46,6 +134,23 @@ type Result[V any] struct {
 	Val    V
 	Err    error
 	Shared bool
+	// SharedCount is the number of callers which received the result, including the leader,
+	// so 1 if it was not shared.
+	SharedCount int
+	// Attempts is the number of times the function was executed to produce the result,
+	// more than 1 if it was retried, see WithRetry.
+	Attempts int
+	// CallID identifies the execution which produced the result, see CallID.
+	CallID uint64
+	// Started and Finished are the times the execution which produced the result
+	// started and completed, to reason about the age of the value.
+	Started  time.Time
+	Finished time.Time
+}
+
+// Age returns the time elapsed since the execution which produced the result completed.
+func (r Result[V]) Age() time.Duration {
+	return time.Since(r.Finished)
 }
 
 // Do executes and returns the results of the given function, making
@@ -55,63 +160,389 @@ type Result[V any] struct {
 // The return value shared indicates whether v was given to multiple callers.
 // Context cancellation should be handled inside the function passed to `Do`,
 // because singleflight does not interrupt the function execution if the context is canceled.
+// The function receives a context derived from ctx, which is canceled when the function
+// returns or when the call is aborted by Drain.
+// After the group is closed Do returns ErrClosed.
+// If the call would wait for itself through nested calls, Do returns a CycleError.
+// If the function panics, the panic is re-raised in the goroutine executing it
+// and waiting callers receive a *PanicError, see WithPanicHandler.
+// When runtime/trace is enabled, the execution is a "singleflight.call" task
+// logging the key and waiting callers are in "singleflight.wait" regions.
 func (g *Group[K, V]) Do(ctx context.Context, key K, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
-	g.mu.Lock()
-	if g.m == nil {
-		g.m = make(map[K]*call[V])
+	res := g.DoDetailed(ctx, key, fn)
+	return res.Val, res.Shared, res.Err
+}
+
+// DoDetailed is like Do but returns the result with its details,
+// like the number of callers which shared it.
+func (g *Group[K, V]) DoDetailed(ctx context.Context, key K, fn doFunc[V]) Result[V] {
+	c, leader, callCtx, ch, err := g.registerDo(ctx, key, fn)
+	if err != nil {
+		return Result[V]{Err: err}
 	}
-	if c, ok := g.m[key]; ok {
-		c.dups++
-		g.mu.Unlock()
-		c.wg.Wait()
-		return c.val, true, c.err
+	if !leader {
+		g.checkFunc(c, key, fn)
+		return g.waitResult(ctx, c, key, ch)
 	}
-	c := new(call[V])
-	c.wg.Add(1)
-	g.m[key] = c
-	g.mu.Unlock()
 
-	g.doCall(ctx, c, key, fn)
-	return c.val, c.dups > 0, c.err
+	g.doCall(callCtx, c, key, fn)
+	return c.result()
+}
+
+// registerDo is register for a caller of Do waiting for the result of the call.
+// ch, if not nil, receives the result, see WithFIFOWakeup.
+func (g *Group[K, V]) registerDo(ctx context.Context, key K, fn any) (
+	c *call[V], leader bool, callCtx context.Context, ch chan Result[V], err error,
+) {
+	if g.options().fifo {
+		ch = make(chan Result[V], 1)
+	}
+	c, leader, callCtx, err = g.register(ctx, key, fn, ch, true)
+	return c, leader, callCtx, ch, err
+}
+
+// waitResult waits for the result of the call c joined by a caller of Do
+// with the channel returned by registerDo.
+func (g *Group[K, V]) waitResult(ctx context.Context, c *call[V], key K, ch chan Result[V]) Result[V] {
+	if ch != nil {
+		endWait := traceWait(ctx)
+		res := <-ch // the wait is reported by finish
+		endWait()
+		return res
+	}
+
+	start := time.Now()
+	endWait := traceWait(ctx)
+	c.wg.Wait()
+	endWait()
+	g.hookWait(c, key, time.Since(start))
+	return c.result()
 }
 
 // DoChan is like Do but returns a channel that will receive the
-// results when they are ready.
+// results when they are ready. The channels of the callers of a call
+// receive the result in the order they joined it.
+// The channel receives exactly one Result: the shared one, or ctx.Err()
+// if ctx is done first.
 func (g *Group[K, V]) DoChan(ctx context.Context, key K, fn doFunc[V]) <-chan Result[V] {
+	ch, _ := g.DoChanCancel(ctx, key, fn)
+	return ch
+}
+
+// DoChanCancel is like DoChan but also returns a function to stop waiting:
+// it removes the channel from the call, which then never receives the result,
+// and no longer counts the caller as sharing it, so ForgetUnshared
+// can forget a call all waiters gave up on. The execution is not interrupted,
+// unless the group cancels abandoned calls, see WithCancelAbandoned.
+// The caller stops waiting automatically when ctx is done: the channel then receives
+// ctx.Err() instead of the shared result, so it always receives exactly one value
+// unless cancel is called. Calling cancel after the result was delivered or more than
+// once has no effect.
+func (g *Group[K, V]) DoChanCancel(ctx context.Context, key K, fn doFunc[V]) (<-chan Result[V], func()) {
+	ch, cancel, err := g.doChanCancel(ctx, key, fn)
+	if err != nil {
+		rejected := make(chan Result[V], 1)
+		rejected <- Result[V]{Err: err}
+		return rejected, func() {}
+	}
+	return ch, cancel
+}
+
+// doChanCancel is DoChanCancel returning the error rejecting the call instead of sending it.
+func (g *Group[K, V]) doChanCancel(ctx context.Context, key K, fn doFunc[V]) (<-chan Result[V], func(), error) {
 	ch := make(chan Result[V], 1)
-	g.mu.Lock()
+	c, leader, callCtx, resumed, err := g.tryRegister(ctx, key, fn, ch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resumed != nil {
+		ch, cancel := g.doChanQueued(ctx, key, fn, resumed)
+		return ch, cancel, nil
+	}
+	if !leader {
+		g.checkFunc(c, key, fn)
+	}
+	if leader {
+		if g.options().syncLeader {
+			g.doCall(callCtx, c, key, fn)
+		} else {
+			go g.doCall(callCtx, c, key, fn)
+		}
+	}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { g.detach(c, leader, ch) })
+	}
+	g.detachOnDone(ctx, c, func() {
+		once.Do(func() {
+			if g.detach(c, leader, ch) {
+				ch <- Result[V]{Err: ctx.Err()}
+			}
+		})
+	})
+	return ch, cancel, nil
+}
+
+// register returns the in-flight call for key, registering a new one if there is none.
+// leader reports whether the caller registered the call and must execute it
+// with the returned leader context. If ch is not nil, it receives the result of the call.
+// If join is false, an in-flight call is returned without joining it.
+// fn, if not nil, is the function of a new call, see WithStrictFuncs.
+func (g *Group[K, V]) register(ctx context.Context, key K, fn any, ch chan<- Result[V], join bool) (
+	c *call[V], leader bool, callCtx context.Context, err error,
+) {
+	c, leader, callCtx, _, err = g.registerWait(ctx, key, fn, ch, join, true)
+	return c, leader, callCtx, err
+}
+
+// tryRegister is register joining the in-flight call for a caller which must not block:
+// if the group is paused with WithPauseQueueing, it registers nothing and returns
+// the channel closed on Resume instead of waiting.
+func (g *Group[K, V]) tryRegister(ctx context.Context, key K, fn any, ch chan<- Result[V]) (
+	c *call[V], leader bool, callCtx context.Context, resumed <-chan struct{}, err error,
+) {
+	return g.registerWait(ctx, key, fn, ch, true, false)
+}
+
+// registerWait implements register and tryRegister: if wait is false, the paused state
+// is checked under the same lock as the registration, so a concurrent Pause can't block.
+func (g *Group[K, V]) registerWait(ctx context.Context, key K, fn any, ch chan<- Result[V], join, wait bool) (
+	c *call[V], leader bool, callCtx context.Context, resumed <-chan struct{}, err error,
+) {
+	if err = checkCycle(ctx, g, key, g.keyLabel(key)); err != nil {
+		return nil, false, nil, nil, err
+	}
+	tenant := g.tenantFor(ctx, key)
+	load := g.checkLoad() // before locking, see LoadProbe
+
+	g.lock()
+	if !wait && g.paused != nil && g.options().pauseQueue {
+		resumed = g.paused
+		g.mu.Unlock()
+		return nil, false, nil, resumed, nil
+	}
+	if err = g.admitCaller(ctx); err != nil {
+		g.mu.Unlock()
+		return nil, false, nil, nil, err
+	}
 	if g.m == nil {
 		g.m = make(map[K]*call[V])
 	}
-	if c, ok := g.m[key]; ok {
+	disabled := g.disabled.Load()
+	c, ok := g.m[key]
+	if ok && join && g.mustRedirect(ctx, c, ch) {
+		ok = false // start a fresh call the next callers join, see WithMaxSubscribers
+	}
+	if ok && !disabled {
+		if !join {
+			g.mu.Unlock()
+			return c, false, nil, nil, nil
+		}
+		if err = g.admit(ctx, c, key, time.Now()); err != nil {
+			g.mu.Unlock()
+			return nil, false, nil, nil, err
+		}
+		if err = g.checkSubscribers(ctx, c, key, ch); err != nil {
+			g.mu.Unlock()
+			return nil, false, nil, nil, err
+		}
+		g.stats.calls.Add(1)
 		c.dups++
-		c.chans = append(c.chans, ch)
+		g.stats.shared.Add(1)
+		g.countClassCall(c, true)
+		g.countJoin(tenant)
+		alert := g.countDedup(true)
+		keys := g.countKey(key)
+		dup := g.trackRequest(ctx, c, key)
+		if ch != nil {
+			c.chans = append(c.chans, ch)
+			if c.joined == nil {
+				c.joined = make(map[chan<- Result[V]]time.Time)
+			}
+			c.joined[ch] = time.Now()
+			g.recordPriority(ctx, c, ch)
+		}
 		g.mu.Unlock()
-		return ch
+		alert()
+		keys()
+		dup()
+		g.hookJoin(c, key)
+		return c, false, nil, nil, nil
 	}
-	c := &call[V]{chans: []chan<- Result[V]{ch}}
-	c.wg.Add(1)
-	g.m[key] = c
+	if err = g.admitLeader(key, tenant, load); err != nil {
+		g.mu.Unlock()
+		return nil, false, nil, nil, err
+	}
+	g.stats.calls.Add(1)
+	c, callCtx = g.newCall(ctx, key)
+	c.tenant = tenant
+	g.claimFunc(c, fn)
+	if !disabled {
+		g.m[key] = c
+	}
+	if ch != nil {
+		c.chans = append(c.chans, ch)
+	}
+	notify := g.crossWatermarks()
+	alert := g.countDedup(false)
+	keys := g.countKey(key)
+	g.trackRequest(ctx, c, key) // a new call has no duplicate
 	g.mu.Unlock()
 
-	go g.doCall(ctx, c, key, fn)
+	notify()
+	alert()
+	keys()
+	return c, true, callCtx, nil, nil
+}
 
-	return ch
+// admitCaller waits while the group is paused and returns an error if the caller with ctx
+// is rejected: the group is closed or the wait for resumption failed.
+// Must be called with g.mu held, which it may release while waiting.
+func (g *Group[K, V]) admitCaller(ctx context.Context) error {
+	if err := g.waitResumed(ctx); err != nil {
+		return err
+	}
+	if g.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// admitLeader returns an error if a new execution for key can't start: the key is
+// cooling down, the process is overloaded, as reported by load, the result of checkLoad,
+// or the quota of tenant is exhausted. On success the quota of tenant is acquired.
+// Must be called with g.mu held.
+func (g *Group[K, V]) admitLeader(key K, tenant string, load error) error {
+	if err := g.checkCooldown(key, time.Now()); err != nil {
+		return err
+	}
+	if load != nil {
+		return load
+	}
+	return g.acquireQuota(tenant)
+}
+
+// newCall registers a new running call for key and returns it
+// together with the context for the leader. The caller decides whether
+// the call is the in-flight call of key other callers join.
+// Must be called with g.mu held.
+func (g *Group[K, V]) newCall(ctx context.Context, key K) (*call[V], context.Context) {
+	c := &call[V]{
+		started: time.Now(),
+		id:      callIDs.Add(1),
+		sampled: g.options().sampler == nil || g.options().sampler(),
+		stack:   g.captureStack(),
+		done:    make(chan struct{}),
+	}
+	if classify := g.options().classify; classify != nil {
+		c.class, c.classified = classify(key), true
+	}
+	ctx, c.cancel = context.WithCancelCause(withChain(g.detachContext(ctx), g, key, g.keyLabel(key)))
+	ctx = g.withTimeout(ctx, c, key)
+	ctx = withCallID(ctx, c)
+	c.ctx = ctx
+	c.wg.Add(1)
+	if g.running == nil {
+		g.running = make(map[*call[V]]K)
+	}
+	g.running[c] = key
+	g.generation++
+	c.gen = g.generation
+	g.stats.executions.Add(1)
+	g.countClassCall(c, false)
+	g.startWatchdog(c, key)
+	g.profileCall(c)
+	return c, ctx
 }
 
 // doCall handles the single call for a key.
 func (g *Group[K, V]) doCall(ctx context.Context, c *call[V], key K, fn doFunc[V]) {
-	c.val, c.err = fn(ctx)
+	ctx, endTask := traceCall(ctx, g.keyLabel(key))
+	defer endTask()
+
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			g.recoverCall(c, key, recover())
+		}
+	}()
 
+	g.hookStart(c, key)
+	v, err := g.retry(ctx, c, g.withCost(key, g.withChaos(key, fn)))
+	normalReturn = true
+	g.finish(c, key, v, err)
+}
+
+// finish completes the call c for key with the given result
+// and delivers it to all waiting callers.
+// If the call was already completed, by Cancel or CompleteInFlight, the result is discarded.
+func (g *Group[K, V]) finish(c *call[V], key K, v V, err error) {
 	g.mu.Lock()
+	if c.isDone() {
+		g.mu.Unlock()
+		return
+	}
+	c.val, c.err = v, err
+	c.finished = time.Now()
+	mutatedVal, mutated := g.checkMutation(c, key) // before the callers receive the value
+	callerCanceled := c.callerCanceled()           // before the context is canceled below
+	c.cancel(nil)
+	if c.err != nil {
+		g.stats.errors.Add(1)
+	}
+	g.countClassFinish(c)
+	if c.onShare != nil {
+		c.onShare(c.dups)
+	}
+	close(c.done)
+	delete(g.running, c)
+	notify := g.crossWatermarks()
+	g.releaseQuota(c.tenant, c.err)
+	for _, stop := range c.stops {
+		stop()
+	}
+	g.unprofileCall(c)
+	if c.watchdog != nil {
+		c.watchdog.Stop()
+	}
 	c.wg.Done()
 	if g.m[key] == c {
 		delete(g.m, key)
 	}
+	res := c.result()
+	now := c.finished
+	waits := make([]time.Duration, 0, len(c.joined))
 	for _, ch := range c.chans {
-		ch <- Result[V]{c.val, c.err, c.dups > 0}
+		ch <- res
+		if joined, ok := c.joined[ch]; ok {
+			waits = append(waits, now.Sub(joined))
+		}
 	}
+	g.watchUnreceived(key, c.chans)
+	g.storeLastResult(key, res)
+	g.recordOutcome(key, c.err)
+	exhausted := g.recordBudget(c.err, callerCanceled)
+	g.observeLatency(key, time.Since(c.started), c.err)
+	g.recordRecent(c, key, now)
+	dups := c.dups
+	callbacks := c.callbacks
 	g.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(res)
+	}
+
+	if mutated {
+		g.options().onMutation(key, mutatedVal)
+	}
+
+	notify()
+	exhausted()
+	g.hookFinish(c, key, dups)
+	for _, d := range waits {
+		g.hookWait(c, key, d)
+	}
 }
 
 // ForgetUnshared tells the singleflight to forget about a key if it is not
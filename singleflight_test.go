@@ -18,14 +18,27 @@ import (
 	"time"
 )
 
+type testCtxKey struct{}
+
+// testContext returns a context which can be recognized by isTestContext
+// in contexts derived from it.
+func testContext() context.Context {
+	return context.WithValue(context.Background(), testCtxKey{}, true)
+}
+
+func isTestContext(ctx context.Context) bool {
+	v, _ := ctx.Value(testCtxKey{}).(bool)
+	return v
+}
+
 func TestDo(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	var g Group[string, string]
 	v, _, err := g.Do(ctx, "key", func(ctxFunc context.Context) (string, error) {
-		if ctxFunc != ctx {
+		if !isTestContext(ctxFunc) {
 			t.Error("wrong context in Do func")
 		}
 		return "bar", nil
@@ -41,12 +54,12 @@ func TestDo(t *testing.T) {
 func TestDoErr(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	var g Group[string, *string]
 	someErr := errors.New("some error")
 	v, _, err := g.Do(ctx, "key", func(ctxFunc context.Context) (*string, error) {
-		if ctxFunc != ctx {
+		if !isTestContext(ctxFunc) {
 			t.Error("wrong context in Do func")
 		}
 		return nil, someErr
@@ -62,14 +75,14 @@ func TestDoErr(t *testing.T) {
 func TestDoDupSuppress(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	var g Group[string, string]
 	var wg1, wg2 sync.WaitGroup
 	c := make(chan string, 1)
 	var calls atomic.Int32
 	fn := func(ctxFunc context.Context) (string, error) {
-		if ctxFunc != ctx {
+		if !isTestContext(ctxFunc) {
 			t.Error("wrong context in Do func")
 		}
 
@@ -116,7 +129,7 @@ func TestDoDupSuppress(t *testing.T) {
 func TestForgetUnshared(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	var g Group[string, int]
 
@@ -129,7 +142,7 @@ func TestForgetUnshared(t *testing.T) {
 	firstCh := make(chan struct{})
 	go func() {
 		_, _, _ = g.Do(ctx, key, func(ctxFunc context.Context) (i int, e error) {
-			if ctxFunc != ctx {
+			if !isTestContext(ctxFunc) {
 				t.Error("wrong context in Do func")
 			}
 
@@ -146,7 +159,7 @@ func TestForgetUnshared(t *testing.T) {
 	secondCh := make(chan struct{})
 	go func() {
 		_, _, _ = g.Do(ctx, key, func(ctxFunc context.Context) (i int, e error) {
-			if ctxFunc != ctx {
+			if !isTestContext(ctxFunc) {
 				t.Error("wrong context in Do func")
 			}
 
@@ -184,7 +197,7 @@ func TestForgetUnshared(t *testing.T) {
 func TestDoAndForgetUnsharedRace(t *testing.T) {
 	t.Parallel()
 
-	ctx := context.Background()
+	ctx := testContext()
 
 	var g Group[string, int64]
 	key := "key"
@@ -197,7 +210,7 @@ func TestDoAndForgetUnsharedRace(t *testing.T) {
 		for i := 0; i < n; i++ {
 			go func() {
 				_, _, _ = g.Do(ctx, key, func(ctxFunc context.Context) (int64, error) {
-					if ctxFunc != ctx {
+					if !isTestContext(ctxFunc) {
 						t.Error("wrong context in Do func")
 					}
 
@@ -229,3 +242,97 @@ func TestDoAndForgetUnsharedRace(t *testing.T) {
 		break
 	}
 }
+
+func TestDoChanCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var g Group[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leaderCh, _ := g.DoChanCancel(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	waiterCh, cancel := g.DoChanCancel(ctx, "key", func(context.Context) (int, error) { return 2, nil })
+	if g.ForgetUnshared("key") {
+		t.Fatal("key with a waiter must not be forgotten")
+	}
+	cancel()
+	cancel() // idempotent
+	if !g.ForgetUnshared("key") {
+		t.Error("key must be forgotten after its only waiter gave up")
+	}
+
+	close(release)
+	if res := <-leaderCh; res.Val != 1 || res.Shared {
+		t.Errorf("leader result = %+v; want 1, not shared", res)
+	}
+	select {
+	case res := <-waiterCh:
+		t.Errorf("canceled waiter received %+v", res)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestDoDetailed(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	ctx := context.Background()
+
+	if res := g.DoDetailed(ctx, "key", func(context.Context) (int, error) { return 1, nil }); res.Val != 1 || res.Shared || res.SharedCount != 1 {
+		t.Errorf("DoDetailed = %+v; want 1 for a single caller", res)
+	}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	leader := g.DoChan(ctx, "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 2, nil
+	})
+	<-started
+
+	const dups = 3
+	results := make(chan Result[int], dups)
+	for range dups {
+		go func() {
+			results <- g.DoDetailed(ctx, "key", func(context.Context) (int, error) { return 3, nil })
+		}()
+	}
+	for g.Stats().Shared < dups {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if res := <-leader; res.SharedCount != dups+1 {
+		t.Errorf("leader SharedCount = %d; want %d", res.SharedCount, dups+1)
+	}
+	for range dups {
+		if res := <-results; res.Val != 2 || !res.Shared || res.SharedCount != dups+1 {
+			t.Errorf("DoDetailed = %+v; want 2 shared by %d callers", res, dups+1)
+		}
+	}
+}
+
+func TestResultTimes(t *testing.T) {
+	t.Parallel()
+
+	var g Group[string, int]
+	before := time.Now()
+	res := g.DoDetailed(context.Background(), "key", func(context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+	if res.Started.Before(before) || res.Finished.Sub(res.Started) < 10*time.Millisecond {
+		t.Errorf("Started = %v, Finished = %v; want an execution of at least 10ms after %v", res.Started, res.Finished, before)
+	}
+	if age := res.Age(); age < 0 || age > time.Since(res.Finished) {
+		t.Errorf("Age = %v", age)
+	}
+}
@@ -0,0 +1,178 @@
+package singleflight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotRecord is a cached value in a snapshot, see Cache.Save.
+type snapshotRecord struct {
+	Key     []byte        `json:"key"`
+	Value   []byte        `json:"value"`
+	Expires time.Time     `json:"expires"`
+	Started time.Time     `json:"started"`
+	Delta   time.Duration `json:"delta"`
+	Tags    []string      `json:"tags,omitempty"`
+}
+
+// WithSnapshotCodecs sets the codecs of keys and values used by Save and Load.
+// The default codecs use encoding/json.
+func WithSnapshotCodecs[K comparable, V any](keys Codec[K], values Codec[V]) CacheOption[K, V] {
+	return func(c *Cache[K, V]) {
+		c.keyCodec = keys
+		c.valCodec = values
+	}
+}
+
+// Save writes the valid cached values to w, so a restarted service can Load them
+// and avoid a cold-start stampede. Values keep their expiry time and tags;
+// dependencies declared with DependsOn are not saved.
+func (c *Cache[K, V]) Save(w io.Writer) error {
+	keyCodec, valCodec := c.codecs()
+	now := time.Now()
+
+	var entries []lruEntry[K, cacheEntry[V]]
+	c.mu.Lock()
+	c.entries.each(func(ent lruEntry[K, cacheEntry[V]]) {
+		if ent.expires.IsZero() || now.Before(ent.expires) {
+			entries = append(entries, ent)
+		}
+	})
+	c.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	for _, ent := range entries { // least recently used first, so Load restores the order
+		key, err := keyCodec.Marshal(ent.key)
+		if err != nil {
+			return fmt.Errorf("singleflight: marshal key %v: %w", c.group.keyLabel(ent.key), err)
+		}
+		val, err := valCodec.Marshal(ent.val.val)
+		if err != nil {
+			return fmt.Errorf("singleflight: marshal value of key %v: %w", c.group.keyLabel(ent.key), err)
+		}
+		if err = enc.Encode(snapshotRecord{
+			Key:     key,
+			Value:   val,
+			Expires: ent.expires,
+			Started: ent.val.started,
+			Delta:   ent.val.delta,
+			Tags:    ent.val.tags,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load adds the values saved by Save to the cache and returns their number.
+// Values which expired meanwhile are skipped.
+func (c *Cache[K, V]) Load(r io.Reader) (int, error) {
+	keyCodec, valCodec := c.codecs()
+	dec := json.NewDecoder(r)
+	n := 0
+	for {
+		var rec snapshotRecord
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, err
+		}
+		if !rec.Expires.IsZero() && !time.Now().Before(rec.Expires) {
+			continue
+		}
+
+		key, err := keyCodec.Unmarshal(rec.Key)
+		if err != nil {
+			return n, fmt.Errorf("singleflight: unmarshal key: %w", err)
+		}
+		val, err := valCodec.Unmarshal(rec.Value)
+		if err != nil {
+			return n, fmt.Errorf("singleflight: unmarshal value of key %v: %w", c.group.keyLabel(key), err)
+		}
+
+		c.put(key, cacheEntry[V]{val: val, started: rec.Started, delta: rec.Delta, tags: rec.Tags}, rec.Expires)
+		n++
+	}
+}
+
+// codecs returns the snapshot codecs of the cache.
+func (c *Cache[K, V]) codecs() (Codec[K], Codec[V]) {
+	var (
+		keyCodec Codec[K] = JSONCodec[K]{}
+		valCodec Codec[V] = JSONCodec[V]{}
+	)
+	if c.keyCodec != nil {
+		keyCodec = c.keyCodec
+	}
+	if c.valCodec != nil {
+		valCodec = c.valCodec
+	}
+	return keyCodec, valCodec
+}
+
+// CacheSnapshot is the in-memory state of a Cache, see Cache.Snapshot.
+type CacheSnapshot[K comparable, V any] struct {
+	// Entries are the cached values, least recently used first.
+	Entries []CacheSnapshotEntry[K, V]
+	// Stats are the counters of the group computing the values.
+	Stats Stats
+}
+
+// CacheSnapshotEntry is a cached value in a CacheSnapshot.
+type CacheSnapshotEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// Expires is the expiry time of the value, zero if it does not expire.
+	Expires time.Time
+	Tags    []string
+}
+
+// Snapshot returns the cached values, including expired ones not yet removed,
+// and the counters of the cache, e.g. for tests asserting on the state of a cache.
+// Unlike Save, values are not encoded.
+func (c *Cache[K, V]) Snapshot() CacheSnapshot[K, V] {
+	s := CacheSnapshot[K, V]{Stats: c.group.Stats()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.each(func(ent lruEntry[K, cacheEntry[V]]) {
+		s.Entries = append(s.Entries, CacheSnapshotEntry[K, V]{
+			Key:     ent.key,
+			Value:   ent.val.val,
+			Expires: ent.expires,
+			Tags:    ent.val.tags,
+		})
+	})
+	return s
+}
+
+// Restore replaces the state of the cache with s, e.g. for tests setting up a warm cache.
+// Values which expired meanwhile are dropped, dependencies declared with DependsOn are cleared
+// and no eviction callback is called for the replaced values.
+// The counters are restored, except InFlight which reflects the running executions.
+func (c *Cache[K, V]) Restore(s CacheSnapshot[K, V]) {
+	c.mu.Lock()
+	c.entries = newLRU[K, cacheEntry[V]](c.size)
+	c.tags = nil
+	c.dependents = nil
+	c.dependencies = nil
+	c.mu.Unlock()
+
+	c.group.stats.calls.Store(s.Stats.Calls)
+	c.group.stats.executions.Store(s.Stats.Executions)
+	c.group.stats.shared.Store(s.Stats.Shared)
+	c.group.stats.errors.Store(s.Stats.Errors)
+
+	now := time.Now()
+	for _, ent := range s.Entries {
+		if !ent.Expires.IsZero() && !now.Before(ent.Expires) {
+			continue
+		}
+		c.put(ent.Key, cacheEntry[V]{val: ent.Value, started: now, tags: ent.Tags}, ent.Expires)
+	}
+}
@@ -0,0 +1,86 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCacheSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	src := NewCache[string, []int](time.Minute)
+	src.Set("a", []int{1})
+	src.Set("b", []int{2, 3})
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewCache(time.Minute, WithCacheSize[string, []int](1))
+	n, err := dst.Load(&buf)
+	if n != 2 || err != nil {
+		t.Fatalf("Load = %d, %v; want 2, nil", n, err)
+	}
+	// the most recently used value survives the smaller size
+	if v, ok := dst.Peek("b"); !ok || len(v) != 2 || v[1] != 3 {
+		t.Errorf("Peek(b) = %v, %v; want [2 3], true", v, ok)
+	}
+	if _, ok := dst.Peek("a"); ok {
+		t.Error("least recently used value must be evicted")
+	}
+}
+
+func TestCacheLoadSkipsExpired(t *testing.T) {
+	t.Parallel()
+
+	src := NewCache[string, int](10 * time.Millisecond)
+	src.Set("a", 1)
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	dst := NewCache[string, int](time.Minute)
+	if n, err := dst.Load(&buf); n != 0 || err != nil {
+		t.Errorf("Load = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestCacheSnapshotRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src := NewCache[string, int](time.Minute)
+	_, _ = src.Get(ctx, "a", func(context.Context) (int, error) { return 1, nil })
+	src.Set("b", 2)
+
+	s := src.Snapshot()
+	if len(s.Entries) != 2 || s.Entries[0].Key != "a" || s.Entries[1].Value != 2 {
+		t.Errorf("entries = %+v; want a, b", s.Entries)
+	}
+	if s.Stats.Executions != 1 {
+		t.Errorf("executions = %d; want 1", s.Stats.Executions)
+	}
+
+	dst := NewCache[string, int](time.Minute)
+	dst.Set("c", 3)
+	s.Entries = append(s.Entries, CacheSnapshotEntry[string, int]{Key: "d", Expires: time.Now().Add(-time.Second)})
+	dst.Restore(s)
+
+	if _, ok := dst.Peek("c"); ok {
+		t.Error("Restore must replace the cached values")
+	}
+	if dst.Len() != 2 {
+		t.Errorf("Len = %d; want 2 without the expired value", dst.Len())
+	}
+	if v, err := dst.Get(ctx, "b", func(context.Context) (int, error) { return 0, nil }); v != 2 || err != nil {
+		t.Errorf("Get(b) = %v, %v; want restored 2", v, err)
+	}
+	if got := dst.Snapshot().Stats; got.Executions != 1 || got.Calls != 1 {
+		t.Errorf("stats = %+v; want restored counters", got)
+	}
+}
@@ -0,0 +1,333 @@
+package singleflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// socketElectionAttempts is the number of attempts to reach or become the broker
+// before a call falls back to local execution.
+const socketElectionAttempts = 10
+
+// socket protocol operations
+const (
+	socketOpAcquire = "acquire" // client -> broker: wait for key or lead it
+	socketOpLead    = "lead"    // broker -> client: execute the function
+	socketOpResult  = "result"  // leader -> broker -> waiters: the result of the call
+)
+
+// socketMessage is a message of the socket protocol, encoded as a JSON line.
+type socketMessage struct {
+	Op   string `json:"op"`
+	Key  string `json:"key,omitempty"`
+	Data []byte `json:"data,omitempty"`
+	Err  string `json:"err,omitempty"`
+}
+
+// SocketGroup shares in-flight calls and their results between processes on one host
+// through a unix domain socket, sitting between the in-process Group and fully
+// distributed coordination. There is no daemon: the first process which takes the lock
+// file next to the socket serves it for the lifetime of its group, and the others connect to it.
+//
+// Results travel between processes through the codec; errors are passed as their text.
+// If the broker is unreachable or goes away during a call, the call is executed locally.
+// If a leader process disconnects, or its context is done, before delivering its result,
+// one of the waiting processes takes over the execution.
+type SocketGroup[V any] struct {
+	path  string
+	codec Codec[V]
+	local Group[string, V]
+
+	mu     sync.Mutex
+	broker *socketBroker // not nil if this process serves the socket
+}
+
+// NewSocketGroup creates a SocketGroup coordinating through the unix socket at path.
+func NewSocketGroup[V any](path string, codec Codec[V]) *SocketGroup[V] {
+	return &SocketGroup[V]{path: path, codec: codec}
+}
+
+// Do executes fn for key unless another process on the host is executing it,
+// in which case it waits for that result. Calls within the process are deduplicated
+// by a Group first. shared reports whether the result was produced by another caller or process.
+func (g *SocketGroup[V]) Do(ctx context.Context, key string, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	var remote bool
+	v, shared, err = g.local.Do(ctx, key, func(ctx context.Context) (V, error) {
+		var err error
+		v, remote, err = g.do(ctx, key, fn)
+		return v, err
+	})
+	return v, shared || remote, err
+}
+
+// Close stops serving the socket if this process is the broker, and closes the group.
+// Processes waiting through this broker fall back to local execution.
+func (g *SocketGroup[V]) Close() {
+	g.local.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.broker != nil {
+		g.broker.close()
+		g.broker = nil
+	}
+}
+
+func (g *SocketGroup[V]) do(ctx context.Context, key string, fn doFunc[V]) (v V, remote bool, err error) {
+	conn, err := g.connect(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return v, false, ctx.Err()
+		}
+		v, err = fn(ctx)
+		return v, false, err
+	}
+	defer conn.Close()
+
+	// closing the connection is the only way to interrupt a blocked read
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	enc := json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+	var msg socketMessage
+	if err = enc.Encode(socketMessage{Op: socketOpAcquire, Key: key}); err == nil {
+		err = dec.Decode(&msg)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return v, false, ctx.Err()
+		}
+		v, err = fn(ctx)
+		return v, false, err
+	}
+
+	if msg.Op == socketOpLead {
+		v, err = fn(ctx)
+		reply := socketMessage{Op: socketOpResult}
+		if err != nil {
+			reply.Err = err.Error()
+		} else if reply.Data, err = g.codec.Marshal(v); err != nil {
+			reply.Err = err.Error()
+		}
+		// Best effort: if the result is lost, the broker promotes a waiter.
+		// The result of a leader whose own context is done is not shared,
+		// a waiter executes the function instead.
+		if ctx.Err() == nil {
+			_ = enc.Encode(reply)
+		}
+		return v, false, err
+	}
+
+	if msg.Err != "" {
+		return v, true, errors.New(msg.Err)
+	}
+	v, err = g.codec.Unmarshal(msg.Data)
+	return v, true, err
+}
+
+// connect connects to the broker, becoming the broker if there is none.
+func (g *SocketGroup[V]) connect(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	for attempt := 0; ; attempt++ {
+		conn, err := d.DialContext(ctx, "unix", g.path)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil || attempt >= socketElectionAttempts {
+			return nil, err
+		}
+
+		if err = g.elect(); err != nil {
+			return nil, err
+		}
+
+		// another process may be starting to serve the socket
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fileLockPollInterval):
+		}
+	}
+}
+
+// elect makes this process the broker if no other process holds the lock.
+func (g *SocketGroup[V]) elect() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.broker != nil {
+		return nil
+	}
+
+	unlock, err := tryLockFile(g.path + ".lock")
+	if err != nil || unlock == nil {
+		return err
+	}
+
+	// the socket file may be left by a broker which crashed
+	_ = os.Remove(g.path)
+	ln, err := net.Listen("unix", g.path)
+	if err != nil {
+		unlock()
+		return err
+	}
+
+	g.broker = newSocketBroker(ln, unlock)
+	go g.broker.serve()
+	return nil
+}
+
+// socketBroker serves the socket of a SocketGroup.
+type socketBroker struct {
+	ln     net.Listener
+	unlock func()
+
+	mu    sync.Mutex // protects conns and calls, serializes writes to connections
+	conns map[net.Conn]struct{}
+	calls map[string]*socketCall
+}
+
+// socketCall is a call in flight through the broker.
+type socketCall struct {
+	leader  *socketConn
+	waiters []*socketConn
+}
+
+type socketConn struct {
+	conn net.Conn
+	enc  *json.Encoder
+}
+
+func newSocketBroker(ln net.Listener, unlock func()) *socketBroker {
+	return &socketBroker{
+		ln:     ln,
+		unlock: unlock,
+		conns:  make(map[net.Conn]struct{}),
+		calls:  make(map[string]*socketCall),
+	}
+}
+
+func (b *socketBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		b.conns[conn] = struct{}{}
+		b.mu.Unlock()
+
+		go b.handle(conn)
+	}
+}
+
+func (b *socketBroker) close() {
+	_ = b.ln.Close()
+
+	b.mu.Lock()
+	for conn := range b.conns {
+		_ = conn.Close()
+	}
+	b.mu.Unlock()
+
+	b.unlock()
+}
+
+// handle serves one call of a client.
+func (b *socketBroker) handle(conn net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	sc := &socketConn{conn: conn, enc: json.NewEncoder(conn)}
+	dec := json.NewDecoder(conn)
+
+	var msg socketMessage
+	if err := dec.Decode(&msg); err != nil || msg.Op != socketOpAcquire {
+		return
+	}
+	key := msg.Key
+
+	b.mu.Lock()
+	if c, ok := b.calls[key]; ok {
+		c.waiters = append(c.waiters, sc)
+	} else {
+		b.calls[key] = &socketCall{leader: sc}
+		_ = sc.enc.Encode(socketMessage{Op: socketOpLead})
+	}
+	b.mu.Unlock()
+
+	// A waiter sends nothing more, so reading detects its disconnection.
+	// A leader, including a promoted waiter, sends the result.
+	for {
+		if err := dec.Decode(&msg); err != nil {
+			b.leave(key, sc)
+			return
+		}
+		if msg.Op == socketOpResult {
+			b.complete(key, sc, msg)
+			return
+		}
+	}
+}
+
+// complete delivers the result sent by the leader sc to all waiters.
+func (b *socketBroker) complete(key string, sc *socketConn, msg socketMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.calls[key]
+	if !ok || c.leader != sc {
+		return
+	}
+	delete(b.calls, key)
+	for _, w := range c.waiters {
+		_ = w.enc.Encode(msg)
+	}
+}
+
+// leave handles the disconnection of sc, promoting a waiter if sc was the leader.
+func (b *socketBroker) leave(key string, sc *socketConn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.calls[key]
+	if !ok {
+		return
+	}
+
+	if c.leader != sc {
+		for i, w := range c.waiters {
+			if w == sc {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	if len(c.waiters) == 0 {
+		delete(b.calls, key)
+		return
+	}
+	c.leader, c.waiters = c.waiters[0], c.waiters[1:]
+	// if the write fails, the handler of the new leader detects the disconnection
+	_ = c.leader.enc.Encode(socketMessage{Op: socketOpLead})
+}
@@ -0,0 +1,111 @@
+//go:build unix
+
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSocketGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sf.sock")
+
+	// Separate SocketGroups on the same socket behave like separate processes.
+	const n = 5
+	groups := make([]*SocketGroup[string], n)
+	for i := range groups {
+		groups[i] = NewSocketGroup[string](path, JSONCodec[string]{})
+		defer groups[i].Close()
+	}
+
+	var (
+		calls atomic.Int32
+		wg    sync.WaitGroup
+	)
+	release := make(chan struct{})
+	for _, g := range groups {
+		g := g
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, err := g.Do(ctx, "key", func(context.Context) (string, error) {
+				calls.Add(1)
+				<-release
+				return "value", nil
+			})
+			if v != "value" || err != nil {
+				t.Errorf("Do = %v, %v; want value, nil", v, err)
+			}
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond) // let all groups join the call
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("function called %d times; want 1", got)
+	}
+
+	someErr := errors.New("some error")
+	if _, _, err := groups[1].Do(ctx, "other", func(context.Context) (string, error) {
+		return "", someErr
+	}); !errors.Is(err, someErr) {
+		t.Errorf("Do error = %v; want %v", err, someErr)
+	}
+}
+
+func TestSocketGroupLeaderGone(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sf.sock")
+	broker := NewSocketGroup[string](path, JSONCodec[string]{})
+	defer broker.Close()
+	leader := NewSocketGroup[string](path, JSONCodec[string]{})
+	waiter := NewSocketGroup[string](path, JSONCodec[string]{})
+
+	// make the first group the broker
+	_, _, _ = broker.Do(context.Background(), "init", func(context.Context) (string, error) { return "", nil })
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		_, _, _ = leader.Do(leaderCtx, "key", func(ctx context.Context) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+	}()
+	<-started
+
+	waiterDone := make(chan string)
+	go func() {
+		v, _, _ := waiter.Do(context.Background(), "key", func(context.Context) (string, error) {
+			return "waiter", nil
+		})
+		waiterDone <- v
+	}()
+
+	time.Sleep(50 * time.Millisecond) // let the waiter join
+	cancel()
+	<-leaderDone
+
+	select {
+	case v := <-waiterDone:
+		if v != "waiter" {
+			t.Errorf("waiter result = %q; want %q", v, "waiter")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not promoted to leader")
+	}
+}
@@ -0,0 +1,57 @@
+package singleflight
+
+import "sync/atomic"
+
+// Stats holds counters describing the activity of a group.
+type Stats struct {
+	// Calls is the number of accepted Do and DoChan calls.
+	Calls uint64 `json:"calls"`
+	// Executions is the number of times a function was executed.
+	Executions uint64 `json:"executions"`
+	// Shared is the number of calls which joined an in-flight call instead of executing.
+	Shared uint64 `json:"shared"`
+	// Errors is the number of executions which returned an error.
+	Errors uint64 `json:"errors"`
+	// InFlight is the number of executions in progress.
+	InFlight int `json:"in_flight"`
+}
+
+// Add returns the sum of s and other.
+func (s Stats) Add(other Stats) Stats {
+	return Stats{
+		Calls:      s.Calls + other.Calls,
+		Executions: s.Executions + other.Executions,
+		Shared:     s.Shared + other.Shared,
+		Errors:     s.Errors + other.Errors,
+		InFlight:   s.InFlight + other.InFlight,
+	}
+}
+
+// groupStats holds the counters of a group.
+type groupStats struct {
+	calls      atomic.Uint64
+	executions atomic.Uint64
+	shared     atomic.Uint64
+	errors     atomic.Uint64
+}
+
+// Stats returns a snapshot of the group counters.
+func (g *Group[K, V]) Stats() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.snapshotStats()
+}
+
+// snapshotStats returns a snapshot of the group counters consistent with the calls
+// in progress, since the counters only change with g.mu held.
+// Must be called with g.mu held.
+func (g *Group[K, V]) snapshotStats() Stats {
+	return Stats{
+		Calls:      g.stats.calls.Load(),
+		Executions: g.stats.executions.Load(),
+		Shared:     g.stats.shared.Load(),
+		Errors:     g.stats.errors.Load(),
+		InFlight:   len(g.running),
+	}
+}
@@ -0,0 +1,196 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// errReaderClosed is returned by reads from a closed stream reader.
+var errReaderClosed = errors.New("singleflight: read from closed stream")
+
+// StreamGroup shares streams: the first caller for a key opens the origin stream,
+// which is spooled in memory and broadcast to every caller joining while it is
+// being read, so concurrent downloads of the same object read the origin once.
+// Each caller reads the whole stream from the beginning at its own pace.
+//
+// The spool is not bounded: it holds the whole stream in memory until the last reader
+// of the stream is closed, so StreamGroup only suits streams that fit in memory.
+// When the last reader is closed before the end of the stream, the context of the
+// origin is canceled and the spool is dropped. The zero value is ready to use.
+type StreamGroup[K comparable] struct {
+	group Group[K, *spool]
+
+	mu     sync.Mutex
+	active map[K]*spool // streams being read from the origin; lazily initialized
+}
+
+// Do returns a reader of the stream for key, opening it with open unless the stream
+// for key is already being opened or read. open receives a context which is not
+// canceled with the context of the caller, since the stream is shared, but is
+// canceled when all the readers of the stream are closed.
+// The caller must close the returned reader.
+func (g *StreamGroup[K]) Do(ctx context.Context, key K, open func(ctx context.Context) (io.ReadCloser, error)) (r io.ReadCloser, shared bool, err error) {
+	for {
+		g.mu.Lock()
+		s, ok := g.active[key]
+		g.mu.Unlock()
+		if ok {
+			if r, ok := s.newReader(); ok {
+				return r, true, nil
+			}
+		}
+
+		s, shared, err = g.group.Do(ctx, key, func(ctx context.Context) (*spool, error) {
+			return g.open(ctx, key, open)
+		})
+		if err != nil {
+			return nil, shared, err
+		}
+		if r, ok := s.newReader(); ok {
+			return r, shared, nil
+		}
+		// all the readers of s were closed meanwhile
+	}
+}
+
+// open opens the origin for key and starts spooling it.
+func (g *StreamGroup[K]) open(ctx context.Context, key K, open func(ctx context.Context) (io.ReadCloser, error)) (*spool, error) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	origin, err := open(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &spool{}
+	s.cond.L = &s.mu
+	s.release = func() {
+		cancel()
+		g.mu.Lock()
+		if g.active[key] == s {
+			delete(g.active, key)
+		}
+		g.mu.Unlock()
+	}
+	g.mu.Lock()
+	if g.active == nil {
+		g.active = make(map[K]*spool)
+	}
+	g.active[key] = s
+	g.mu.Unlock()
+
+	go func() {
+		s.fill(origin)
+		_ = origin.Close()
+
+		g.mu.Lock()
+		if g.active[key] == s {
+			delete(g.active, key)
+		}
+		g.mu.Unlock()
+	}()
+	return s, nil
+}
+
+// spool is a stream buffered in memory while it is read from the origin.
+type spool struct {
+	mu       sync.Mutex
+	cond     sync.Cond
+	buf      []byte
+	done     bool
+	err      error // error of the origin, io.EOF at the end
+	readers  int   // readers not closed yet
+	released bool  // all readers were closed, see newReader
+	release  func()
+}
+
+// newReader returns a new reader of s, unless all the readers of s were closed.
+func (s *spool) newReader() (*spoolReader, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return nil, false
+	}
+	s.readers++
+	return &spoolReader{s: s}, true
+}
+
+// fill reads the origin into the spool until its end or the release of the spool.
+func (s *spool) fill(origin io.Reader) {
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := origin.Read(chunk)
+
+		s.mu.Lock()
+		released := s.released
+		if !released {
+			s.buf = append(s.buf, chunk[:n]...)
+		}
+		if err != nil {
+			s.done = true
+			s.err = err
+		}
+		s.mu.Unlock()
+		s.cond.Broadcast()
+
+		if err != nil || released {
+			return
+		}
+	}
+}
+
+// spoolReader reads a spool from the beginning.
+type spoolReader struct {
+	s      *spool
+	off    int
+	closed bool // protected by s.mu
+}
+
+// Read implements io.Reader, waiting for the origin if the reader caught up with it.
+func (r *spoolReader) Read(p []byte) (int, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !r.closed && r.off == len(s.buf) && !s.done {
+		s.cond.Wait()
+	}
+	if r.closed {
+		return 0, errReaderClosed
+	}
+	if r.off < len(s.buf) {
+		n := copy(p, s.buf[r.off:])
+		r.off += n
+		return n, nil
+	}
+	return 0, s.err
+}
+
+// Close implements io.Closer, unblocking a pending Read.
+// Closing the last reader of the stream releases the spool and cancels the origin
+// if it is still being read.
+func (r *spoolReader) Close() error {
+	s := r.s
+	s.mu.Lock()
+	if r.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	s.readers--
+	release := s.readers == 0
+	if release {
+		s.released = true
+		s.buf = nil
+	}
+	s.mu.Unlock()
+	s.cond.Broadcast()
+
+	if release {
+		s.release()
+	}
+	return nil
+}
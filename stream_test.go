@@ -0,0 +1,157 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// slowReader returns its data in small pieces, with a delay before each.
+type slowReader struct {
+	data string
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, io.EOF
+	}
+	time.Sleep(time.Millisecond)
+	n := copy(p[:min(len(p), 3)], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestStreamGroup(t *testing.T) {
+	t.Parallel()
+
+	var g StreamGroup[string]
+	const payload = "the quick brown fox jumps over the lazy dog"
+
+	var opens atomic.Int32
+	open := func(context.Context) (io.ReadCloser, error) {
+		opens.Add(1)
+		return io.NopCloser(&slowReader{data: payload}), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, _, err := g.Do(context.Background(), "object", open)
+			if err != nil {
+				t.Errorf("Do error = %v", err)
+				return
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if string(got) != payload || err != nil {
+				t.Errorf("read %q, %v; want %q", got, err, payload)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := opens.Load(); got != 1 {
+		t.Errorf("origin opened %d times; want 1", got)
+	}
+}
+
+func TestStreamGroupError(t *testing.T) {
+	t.Parallel()
+
+	var g StreamGroup[string]
+	someErr := errors.New("some error")
+	if _, _, err := g.Do(context.Background(), "object", func(context.Context) (io.ReadCloser, error) {
+		return nil, someErr
+	}); !errors.Is(err, someErr) {
+		t.Errorf("Do error = %v; want %v", err, someErr)
+	}
+
+	r, _, err := g.Do(context.Background(), "broken", func(context.Context) (io.ReadCloser, error) {
+		return io.NopCloser(io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(someErr))), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if string(got) != "abc" || !errors.Is(err, someErr) {
+		t.Errorf("read %q, %v; want abc, %v", got, err, someErr)
+	}
+}
+
+// blockingReader blocks reads until its context is done.
+type blockingReader struct {
+	ctx context.Context
+}
+
+func (r blockingReader) Read([]byte) (int, error) {
+	<-r.ctx.Done()
+	return 0, r.ctx.Err()
+}
+
+func TestStreamGroupCancel(t *testing.T) {
+	t.Parallel()
+
+	var g StreamGroup[string]
+	canceled := make(chan struct{})
+	open := func(ctx context.Context) (io.ReadCloser, error) {
+		context.AfterFunc(ctx, func() { close(canceled) })
+		return io.NopCloser(blockingReader{ctx}), nil
+	}
+
+	r1, _, err := g.Do(context.Background(), "object", open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, shared, err := g.Do(context.Background(), "object", open)
+	if err != nil || !shared {
+		t.Fatalf("Do = %v, %v; want a shared reader", shared, err)
+	}
+
+	_ = r1.Close()
+	select {
+	case <-canceled:
+		t.Fatal("origin canceled while a reader is open")
+	case <-time.After(10 * time.Millisecond):
+	}
+	_ = r2.Close()
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("origin not canceled after the last reader was closed")
+	}
+}
+
+func TestStreamGroupCloseUnblocksRead(t *testing.T) {
+	t.Parallel()
+
+	var g StreamGroup[string]
+	r, _, err := g.Do(context.Background(), "object", func(ctx context.Context) (io.ReadCloser, error) {
+		return io.NopCloser(blockingReader{ctx}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 8))
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = r.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errReaderClosed) {
+			t.Errorf("Read = %v; want %v", err, errReaderClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't unblock a pending Read")
+	}
+}
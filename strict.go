@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// FuncMismatchError describes callers passing different functions for the same key,
+// see WithStrictFuncs.
+type FuncMismatchError struct {
+	// Key is the key of the call, redacted if the group has a key redactor.
+	Key any
+	// Executed is the name of the function of the call, Other the name of the function
+	// passed by a caller joining it.
+	Executed, Other string
+}
+
+// Error implements error.
+func (e *FuncMismatchError) Error() string {
+	return fmt.Sprintf("singleflight: key %v executes %s but a caller joining it passed %s",
+		e.Key, e.Executed, e.Other)
+}
+
+// WithStrictFuncs is a debug mode detecting callers joining a call with a different function
+// than the one the call was started with: one of them silently receives the result of the
+// other function, a subtle bug the API permits. Functions are identified by their code, so
+// closures created by the same function literal are considered identical.
+// A mismatch is reported to report, or raised as a panic with a *FuncMismatchError if report is nil.
+// It applies to Do, DoChan and DoChanCancel.
+func WithStrictFuncs[K comparable, V any](report func(*FuncMismatchError)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.strict = true
+		o.onMismatch = report
+	}
+}
+
+// claimFunc records fn as the function of the new call c, see checkFunc.
+// Must be called with g.mu held.
+func (g *Group[K, V]) claimFunc(c *call[V], fn any) {
+	if fn != nil && g.options().strict {
+		c.fnPC = reflect.ValueOf(fn).Pointer()
+	}
+}
+
+// checkFunc verifies that fn, passed by a caller joining the call c for key, is the function
+// the call was started with. It must not be called by the leader, which must execute the
+// call whatever happens, since the other callers wait for it.
+func (g *Group[K, V]) checkFunc(c *call[V], key K, fn any) {
+	opts := g.options()
+	if !opts.strict {
+		return
+	}
+
+	pc := reflect.ValueOf(fn).Pointer()
+	g.mu.Lock()
+	executed := c.fnPC
+	g.mu.Unlock()
+	if executed == 0 || executed == pc {
+		return
+	}
+
+	err := &FuncMismatchError{Key: g.keyLabel(key), Executed: funcName(executed), Other: funcName(pc)}
+	if opts.onMismatch == nil {
+		panic(err)
+	}
+	opts.onMismatch(err)
+}
+
+// funcName returns the name of the function at pc.
+func funcName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc); f != nil {
+		return f.Name()
+	}
+	return fmt.Sprintf("func@%#x", pc)
+}
@@ -0,0 +1,108 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStrictFuncs(t *testing.T) {
+	t.Parallel()
+
+	var mismatches []*FuncMismatchError
+	g := NewGroup(WithStrictFuncs[string, int](func(err *FuncMismatchError) {
+		mismatches = append(mismatches, err)
+	}))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	load := func(n int) doFunc[int] {
+		return func(context.Context) (int, error) {
+			if n == 1 {
+				close(started)
+				<-release
+			}
+			return n, nil
+		}
+	}
+	ch := g.DoChan(context.Background(), "key", load(1))
+	<-started
+	same := g.DoChan(context.Background(), "key", load(2))
+	other := g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 3, nil })
+	close(release)
+	<-ch
+	<-same
+	<-other
+
+	if len(mismatches) != 1 {
+		t.Fatalf("mismatches = %v; want 1 for the other function literal", mismatches)
+	}
+	if err := mismatches[0]; err.Key != "key" || !strings.Contains(err.Executed, "TestStrictFuncs") || err.Executed == err.Other {
+		t.Errorf("mismatch = %v", err)
+	}
+}
+
+func TestStrictFuncsPanic(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithStrictFuncs[string, int](nil))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	defer func() {
+		close(release)
+		<-ch
+		var err *FuncMismatchError
+		if r, _ := recover().(error); !errors.As(r, &err) {
+			t.Errorf("recovered %v; want a *FuncMismatchError", r)
+		}
+	}()
+	_, _, _ = g.Do(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+	t.Error("Do must panic on a mismatch")
+}
+
+func TestStrictFuncsLeaderRace(t *testing.T) {
+	t.Parallel()
+
+	var g *Group[string, int]
+	other := func(context.Context) (int, error) { return 2, nil }
+	followed := make(chan any, 1)
+	// joins the call between its registration and its execution by the leader
+	g = NewGroup(
+		WithStrictFuncs[string, int](nil),
+		WithHighWatermark[string, int](1, func(int) {
+			go func() {
+				defer func() { followed <- recover() }()
+				_, _, _ = g.Do(context.Background(), "key", other)
+			}()
+			for g.Stats().Shared == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond) // let the follower check its function
+		}),
+	)
+
+	if v, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("leader Do = %v, %v; want 1, nil", v, err)
+	}
+	var mismatch *FuncMismatchError
+	if r, _ := (<-followed).(error); !errors.As(r, &mismatch) || mismatch.Other != funcName(reflect.ValueOf(other).Pointer()) {
+		t.Errorf("follower recovered %v; want a mismatch naming the function of the leader", r)
+	}
+	select {
+	case res := <-g.DoChan(context.Background(), "key", other):
+		if res.Val != 2 {
+			t.Errorf("later call = %+v; want a new execution", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("key is stuck after a mismatch")
+	}
+}
@@ -0,0 +1,51 @@
+package singleflight
+
+import "context"
+
+// SubGroup is a view of a Group whose keys are namespaced before reaching the parent.
+// Different modules can share one underlying Group (and its options, hooks and stats)
+// through their own sub-groups without key collisions.
+type SubGroup[K comparable, V any] struct {
+	parent *Group[K, V]
+	mapKey func(K) K
+}
+
+// SubGroup returns a view of g which maps every key with mapKey.
+// mapKey must be deterministic, and different sub-groups sharing g
+// must map their keys to disjoint sets to avoid collisions.
+func (g *Group[K, V]) SubGroup(mapKey func(K) K) *SubGroup[K, V] {
+	return &SubGroup[K, V]{parent: g, mapKey: mapKey}
+}
+
+// Prefix returns a key mapper for SubGroup which prepends prefix to string keys.
+func Prefix(prefix string) func(string) string {
+	return func(key string) string {
+		return prefix + key
+	}
+}
+
+// SubGroup returns a nested view whose keys are mapped with mapKey
+// and then with the mapper of s.
+func (s *SubGroup[K, V]) SubGroup(mapKey func(K) K) *SubGroup[K, V] {
+	return &SubGroup[K, V]{
+		parent: s.parent,
+		mapKey: func(key K) K {
+			return s.mapKey(mapKey(key))
+		},
+	}
+}
+
+// Do is like Group.Do for the namespaced key.
+func (s *SubGroup[K, V]) Do(ctx context.Context, key K, fn doFunc[V]) (v V, shared bool, err error) { // nolint: revive
+	return s.parent.Do(ctx, s.mapKey(key), fn)
+}
+
+// DoChan is like Group.DoChan for the namespaced key.
+func (s *SubGroup[K, V]) DoChan(ctx context.Context, key K, fn doFunc[V]) <-chan Result[V] {
+	return s.parent.DoChan(ctx, s.mapKey(key), fn)
+}
+
+// ForgetUnshared is like Group.ForgetUnshared for the namespaced key.
+func (s *SubGroup[K, V]) ForgetUnshared(key K) bool {
+	return s.parent.ForgetUnshared(s.mapKey(key))
+}
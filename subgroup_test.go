@@ -0,0 +1,55 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var g Group[string, string]
+	users := g.SubGroup(Prefix("users:"))
+	admins := users.SubGroup(Prefix("admins:"))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := users.DoChan(ctx, "1", func(context.Context) (string, error) {
+		close(started)
+		<-release
+		return "user", nil
+	})
+	<-started
+
+	dup := users.DoChan(ctx, "1", func(context.Context) (string, error) {
+		t.Error("duplicate call must not be executed")
+		return "", nil
+	})
+	if users.ForgetUnshared("1") {
+		t.Error("shared sub-group key must not be forgotten")
+	}
+
+	v, shared, err := admins.Do(ctx, "1", func(context.Context) (string, error) {
+		return "admin", nil
+	})
+	if v != "admin" || shared || err != nil {
+		t.Errorf("admins.Do = %v, %v, %v; want admin, false, nil", v, shared, err)
+	}
+
+	v, _, err = g.Do(ctx, "users:admins:1", func(context.Context) (string, error) {
+		return "parent", nil
+	})
+	if v != "parent" || err != nil {
+		t.Errorf("parent Do = %v, %v; want parent, nil", v, err)
+	}
+
+	close(release)
+	if res := <-ch; res.Val != "user" {
+		t.Errorf("users.DoChan = %v; want user", res.Val)
+	}
+	if res := <-dup; res.Val != "user" || !res.Shared {
+		t.Errorf("duplicate users.DoChan = %+v; want shared user", res)
+	}
+}
@@ -0,0 +1,14 @@
+package singleflight
+
+// WithSyncLeader makes DoChan and DoChanCancel execute the function of a new call inline,
+// in the goroutine of the caller which starts it, instead of a new goroutine:
+// that caller gets its channel back, already holding the result, once the function returns,
+// while the channel of a joining caller is returned immediately.
+// It saves a goroutine per unique key in high-throughput services where the first
+// caller waits for the result anyway. A panic of the function is re-raised in the
+// goroutine of that caller. DoCallback, whose caller doesn't wait, is not affected.
+func WithSyncLeader[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.syncLeader = true
+	}
+}
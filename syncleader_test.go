@@ -0,0 +1,30 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSyncLeader(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithSyncLeader[string, int]())
+
+	var joined <-chan Result[int]
+	ch := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		joined = g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil })
+		return 1, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Val != 1 || !res.Shared {
+			t.Errorf("leader result = %+v; want shared 1", res)
+		}
+	default:
+		t.Fatal("leader channel must hold the result when DoChan returns")
+	}
+	if res := <-joined; res.Val != 1 {
+		t.Errorf("joined result = %+v; want 1", res)
+	}
+}
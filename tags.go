@@ -0,0 +1,100 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// Meta is metadata returned by a function together with the value it computed.
+type Meta struct {
+	// Tags label the value, so all values with a tag can be invalidated at once,
+	// see Cache.InvalidateTag.
+	Tags []string
+	// TTL, if positive, replaces the TTL of the cache for the value,
+	// e.g. derived from Cache-Control or the expiry of a record.
+	TTL time.Duration
+}
+
+// MetaFunc computes a value and its metadata.
+type MetaFunc[V any] func(ctx context.Context) (V, Meta, error)
+
+// withoutMeta adapts fn to a MetaFunc returning no metadata.
+func withoutMeta[V any](fn doFunc[V]) MetaFunc[V] {
+	return func(ctx context.Context) (V, Meta, error) {
+		v, err := fn(ctx)
+		return v, Meta{}, err
+	}
+}
+
+// GetMeta is like Get but fn also returns metadata, such as tags or a TTL, stored with the value.
+func (c *Cache[K, V]) GetMeta(ctx context.Context, key K, fn MetaFunc[V]) (V, error) {
+	return c.get(ctx, key, time.Time{}, fn)
+}
+
+// InvalidateTag removes all cached values tagged with tag, and the values depending on them,
+// and returns their number. It is meant for an upstream change which invalidates many keys at once.
+// It does not affect computations in flight.
+func (c *Cache[K, V]) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	var removed []lruEntry[K, cacheEntry[V]]
+	visited := make(map[K]struct{})
+	for key := range c.tags[tag] {
+		removed = c.invalidate(key, visited, removed)
+	}
+	c.mu.Unlock()
+
+	c.evicted(EvictForgotten, removed...)
+	return len(removed)
+}
+
+// remove deletes the entry for key and its tags.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) remove(key K) (lruEntry[K, cacheEntry[V]], bool) {
+	ent, ok := c.entries.remove(key)
+	if ok {
+		c.untag(ent)
+		c.undepend(key)
+	}
+	return ent, ok
+}
+
+// removeExpired deletes the entry for key and its tags if it is expired at now.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) removeExpired(key K, now time.Time) (lruEntry[K, cacheEntry[V]], bool) {
+	ent, ok := c.entries.removeExpired(key, now)
+	if ok {
+		c.untag(ent)
+		c.undepend(key)
+	}
+	return ent, ok
+}
+
+// tag indexes key under tags.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) tag(key K, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if c.tags == nil {
+		c.tags = make(map[string]map[K]struct{})
+	}
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untag removes the removed entry ent from the tag index.
+// Must be called with c.mu held.
+func (c *Cache[K, V]) untag(ent lruEntry[K, cacheEntry[V]]) {
+	for _, tag := range ent.val.tags {
+		delete(c.tags[tag], ent.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
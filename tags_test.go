@@ -0,0 +1,65 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCacheInvalidateTag(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](time.Minute)
+
+	tagged := func(v int, tags ...string) MetaFunc[int] {
+		return func(context.Context) (int, Meta, error) {
+			return v, Meta{Tags: tags}, nil
+		}
+	}
+	_, _ = c.GetMeta(ctx, "user:1", tagged(1, "users", "org:1"))
+	_, _ = c.GetMeta(ctx, "user:2", tagged(2, "users", "org:2"))
+	_, _ = c.GetMeta(ctx, "org:1", tagged(3, "org:1"))
+
+	if n := c.InvalidateTag("org:1"); n != 2 {
+		t.Errorf("InvalidateTag(org:1) = %d; want 2", n)
+	}
+	if _, ok := c.Peek("user:1"); ok {
+		t.Error("user:1 must be invalidated")
+	}
+	if _, ok := c.Peek("user:2"); !ok {
+		t.Error("user:2 must stay cached")
+	}
+
+	// replaced values lose their old tags
+	c.Set("user:2", 20)
+	if n := c.InvalidateTag("users"); n != 0 {
+		t.Errorf("InvalidateTag(users) = %d; want 0 after user:2 was replaced", n)
+	}
+	if len(c.tags) != 0 {
+		t.Errorf("tag index = %v; want empty", c.tags)
+	}
+}
+
+func TestCacheMetaTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache[string, int](time.Minute)
+
+	withTTL := func(v int, ttl time.Duration) MetaFunc[int] {
+		return func(context.Context) (int, Meta, error) {
+			return v, Meta{TTL: ttl}, nil
+		}
+	}
+	_, _ = c.GetMeta(ctx, "short", withTTL(1, 10*time.Millisecond))
+	_, _ = c.GetMeta(ctx, "default", withTTL(2, 0))
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Peek("short"); ok {
+		t.Error("short must expire after its own TTL")
+	}
+	if _, ok := c.Peek("default"); !ok {
+		t.Error("default must keep the TTL of the cache")
+	}
+}
@@ -0,0 +1,167 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// tenantState holds the in-flight calls and the counters of a tenant.
+type tenantState struct {
+	inFlight   int
+	calls      uint64
+	executions uint64
+	shared     uint64
+	errors     uint64
+}
+
+// WithTenantFunc sets the function returning the tenant of a call, e.g. from a key prefix,
+// so one group can safely serve a multi-tenant service: quotas (WithIdentityQuota and
+// WithTenantLimits) apply per tenant and statistics are reported per tenant, see TenantStats.
+// An empty tenant means none. By default the tenant is the identity set with WithIdentity.
+// fn is called for every call, without holding the lock of the group. It must return
+// a bounded set of tenants: the statistics of at most 1024 tenants are kept while they
+// have no call in flight, others are dropped.
+func WithTenantFunc[K comparable, V any](fn func(ctx context.Context, key K) string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.tenantOf = fn
+	}
+}
+
+// WithTenantLimits sets the maximum number of in-flight calls led by given tenants,
+// overriding the quota of WithIdentityQuota for them.
+func WithTenantLimits[K comparable, V any](limits map[string]int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.tenantLimits = limits
+	}
+}
+
+// TenantStats returns the counters of a tenant. Without WithTenantFunc, only tenants
+// with calls in flight have counters.
+func (g *Group[K, V]) TenantStats(tenant string) Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st, ok := g.tenants[tenant]
+	if !ok {
+		return Stats{}
+	}
+	return Stats{
+		Calls:      st.calls,
+		Executions: st.executions,
+		Shared:     st.shared,
+		Errors:     st.errors,
+		InFlight:   st.inFlight,
+	}
+}
+
+// Tenants returns the tenants seen by the group, sorted.
+func (g *Group[K, V]) Tenants() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tenants := make([]string, 0, len(g.tenants))
+	for tenant := range g.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// maxTenantStats is the number of tenants whose statistics are kept while they have
+// no call in flight, see WithTenantFunc.
+const maxTenantStats = 1024
+
+// tenantFor returns the tenant of a call for key with ctx, or "" if tenants are not tracked
+// or the call has no tenant. It calls the tenant function, so it must be called without g.mu.
+func (g *Group[K, V]) tenantFor(ctx context.Context, key K) string {
+	opts := g.options()
+	if opts.quota <= 0 && opts.tenantOf == nil && opts.tenantLimits == nil {
+		return ""
+	}
+	if opts.tenantOf != nil {
+		return opts.tenantOf(ctx, key)
+	}
+	identity, _ := IdentityFromContext(ctx)
+	return identity
+}
+
+// retainTenants reports whether the state of idle tenants is kept for their statistics:
+// only tenants of WithTenantFunc, a bounded set, up to maxTenantStats of them.
+// Identities are unbounded, so their state is dropped once they have no call in flight.
+// Must be called with g.mu held.
+func (g *Group[K, V]) retainTenants() bool {
+	return g.options().tenantOf != nil && len(g.tenants) < maxTenantStats
+}
+
+// acquireQuota reserves a call for tenant, or returns an error if the quota
+// of the tenant is exhausted.
+// Must be called with g.mu held.
+func (g *Group[K, V]) acquireQuota(tenant string) error {
+	if tenant == "" {
+		return nil
+	}
+	st, ok := g.tenants[tenant]
+	if !ok {
+		st = &tenantState{}
+	}
+
+	limit := g.options().quota
+	if l, ok := g.options().tenantLimits[tenant]; ok {
+		limit = l
+	}
+	if limit > 0 && st.inFlight >= limit {
+		return fmt.Errorf("%w: %q leads %d calls", ErrQuotaExceeded, tenant, st.inFlight)
+	}
+
+	if !ok {
+		if g.tenants == nil {
+			g.tenants = make(map[string]*tenantState)
+		}
+		g.tenants[tenant] = st
+	}
+	st.inFlight++
+	st.calls++
+	st.executions++
+	return nil
+}
+
+// countJoin counts a caller of tenant joining an in-flight call.
+// Must be called with g.mu held.
+func (g *Group[K, V]) countJoin(tenant string) {
+	if tenant == "" {
+		return
+	}
+	st, ok := g.tenants[tenant]
+	if !ok {
+		if !g.retainTenants() {
+			return
+		}
+		if g.tenants == nil {
+			g.tenants = make(map[string]*tenantState)
+		}
+		st = &tenantState{}
+		g.tenants[tenant] = st
+	}
+	st.calls++
+	st.shared++
+}
+
+// releaseQuota releases a call reserved for tenant by acquireQuota, which completed with err.
+// Must be called with g.mu held.
+func (g *Group[K, V]) releaseQuota(tenant string, err error) {
+	st, ok := g.tenants[tenant]
+	if !ok {
+		return
+	}
+	st.inFlight--
+	if err != nil {
+		st.errors++
+	}
+	if st.inFlight == 0 {
+		delete(g.tenants, tenant)
+		if g.retainTenants() {
+			g.tenants[tenant] = st
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := NewGroup(
+		WithTenantFunc[string, int](func(_ context.Context, key string) string {
+			tenant, _, _ := strings.Cut(key, "/")
+			return tenant
+		}),
+		WithTenantLimits[string, int](map[string]int{"small": 1}),
+	)
+
+	release := make(chan struct{})
+	slow := func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}
+	s1 := g.DoChan(ctx, "small/1", slow)
+	if _, _, err := g.Do(ctx, "small/2", slow); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("error = %v; want %v", err, ErrQuotaExceeded)
+	}
+	s1dup := g.DoChan(ctx, "small/1", slow)
+	b1 := g.DoChan(ctx, "big/1", slow)
+	b2 := g.DoChan(ctx, "big/2", slow)
+
+	if st := g.TenantStats("big"); st.InFlight != 2 {
+		t.Errorf("big in flight = %d; want 2", st.InFlight)
+	}
+	close(release)
+	for _, ch := range []<-chan Result[int]{s1, s1dup, b1, b2} {
+		<-ch
+	}
+	_, _, _ = g.Do(ctx, "big/3", func(context.Context) (int, error) { return 0, errors.New("fail") })
+
+	if got := g.Tenants(); len(got) != 2 || got[0] != "big" || got[1] != "small" {
+		t.Errorf("Tenants = %v; want [big small]", got)
+	}
+	if st := g.TenantStats("small"); st.Calls != 2 || st.Executions != 1 || st.Shared != 1 || st.InFlight != 0 {
+		t.Errorf("small stats = %+v", st)
+	}
+	if st := g.TenantStats("big"); st.Executions != 3 || st.Errors != 1 || st.InFlight != 0 {
+		t.Errorf("big stats = %+v", st)
+	}
+}
+
+func TestTenantsReclaimed(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(WithIdentityQuota[string, int](1))
+	for i := range 100 {
+		ctx := WithIdentity(context.Background(), "user-"+strconv.Itoa(i))
+		_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) { return 1, nil })
+	}
+	if tenants := g.Tenants(); len(tenants) != 0 {
+		t.Errorf("Tenants = %d; want idle identities dropped", len(tenants))
+	}
+}
+
+func TestTenantFuncUnlocked(t *testing.T) {
+	t.Parallel()
+
+	var g *Group[string, int]
+	g = NewGroup(WithTenantFunc[string, int](func(context.Context, string) string {
+		_ = g.Stats() // would deadlock if called with the lock of the group held
+		return "tenant"
+	}))
+	if v, _, err := g.Do(context.Background(), "key", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do = %v, %v", v, err)
+	}
+}
@@ -0,0 +1,67 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the cancellation cause of the context of an execution which exceeded
+// the timeout of the group, see WithTimeout. It matches context.DeadlineExceeded.
+type TimeoutError struct {
+	// Group is the name of the group, see WithName.
+	Group string
+	// Key is the key of the call, redacted if the group has a key redactor.
+	Key     any
+	Timeout time.Duration
+}
+
+// Error implements error.
+func (e *TimeoutError) Error() string {
+	if e.Group == "" {
+		return fmt.Sprintf("singleflight key=%v exceeded %s", e.Key, e.Timeout)
+	}
+	return fmt.Sprintf("singleflight group=%s key=%v exceeded %s", e.Group, e.Key, e.Timeout)
+}
+
+// Is reports whether target is context.DeadlineExceeded.
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded // nolint: errorlint
+}
+
+// WithTimeout bounds the execution time of functions: the context of an execution is canceled
+// after d with a *TimeoutError as the cause, naming the group and the key, so logs show
+// "singleflight group=users key=user:42 exceeded 2s" rather than a bare deadline error.
+func WithTimeout[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.timeout = d
+	}
+}
+
+// WithName names the group in the errors it reports, e.g. a *TimeoutError.
+// The groups of a GroupSet are named after their name in the set.
+func WithName[K comparable, V any](name string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.name = name
+	}
+}
+
+// withTimeout returns ctx bounded by the timeout of the group for the call c for key.
+func (g *Group[K, V]) withTimeout(ctx context.Context, c *call[V], key K) context.Context {
+	opts := g.options()
+	if opts.timeout <= 0 {
+		return ctx
+	}
+
+	ctx, stop := context.WithTimeoutCause(ctx, opts.timeout, &TimeoutError{
+		Group:   opts.name,
+		Key:     g.keyLabel(key),
+		Timeout: opts.timeout,
+	})
+	cancel := c.cancel
+	c.cancel = func(cause error) {
+		cancel(cause)
+		stop()
+	}
+	return ctx
+}
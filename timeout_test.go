@@ -0,0 +1,30 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroupSet(WithTimeout[string, int](10 * time.Millisecond)).Get("users")
+
+	_, _, err := g.Do(context.Background(), "user:42", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	})
+	var terr *TimeoutError
+	if !errors.As(err, &terr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do error = %v; want a *TimeoutError matching %v", err, context.DeadlineExceeded)
+	}
+	if got, want := err.Error(), "singleflight group=users key=user:42 exceeded 10ms"; got != want {
+		t.Errorf("error = %q; want %q", got, want)
+	}
+
+	if v, _, err := g.Do(context.Background(), "fast", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Do = %v, %v; want 1, nil", v, err)
+	}
+}
@@ -0,0 +1,33 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+)
+
+// Names of the runtime/trace task and region of calls, see traceCall and traceWait.
+const (
+	traceTaskCall   = "singleflight.call"
+	traceRegionWait = "singleflight.wait"
+)
+
+// traceCall starts a runtime/trace task for the execution of a call,
+// logging its key label, when tracing is enabled. The returned function ends the task.
+func traceCall(ctx context.Context, label any) (context.Context, func()) {
+	if !trace.IsEnabled() {
+		return ctx, func() {}
+	}
+	ctx, task := trace.NewTask(ctx, traceTaskCall)
+	trace.Log(ctx, "key", fmt.Sprint(label))
+	return ctx, task.End
+}
+
+// traceWait starts a runtime/trace region for a caller waiting for a shared call,
+// when tracing is enabled. The returned function ends the region.
+func traceWait(ctx context.Context) func() {
+	if !trace.IsEnabled() {
+		return func() {}
+	}
+	return trace.StartRegion(ctx, traceRegionWait).End
+}
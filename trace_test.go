@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRuntimeTrace(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("tracing unavailable: %v", err)
+	}
+
+	var g Group[string, int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _, _ = g.Do(context.Background(), "traced-key", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		_, _, _ = g.Do(context.Background(), "traced-key", func(context.Context) (int, error) { return 2, nil })
+	}()
+	for g.Stats().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	trace.Stop()
+
+	for _, s := range []string{traceTaskCall, traceRegionWait, "traced-key"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("trace does not contain %q", s)
+		}
+	}
+}
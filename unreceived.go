@@ -0,0 +1,35 @@
+package singleflight
+
+import "time"
+
+// WithUnreceivedReport calls fn with the key and the number of DoChan channels whose
+// result was still not received grace after the call completed, to find the goroutine
+// and memory leaks caused by forgotten receivers. Channels of callers which stopped
+// waiting, see DoChanCancel, are not reported.
+// fn is called from its own goroutine.
+func WithUnreceivedReport[K comparable, V any](grace time.Duration, fn func(key K, label any, n int)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.unreceivedGrace = grace
+		o.onUnreceived = fn
+	}
+}
+
+// watchUnreceived reports the channels among chans still holding the result of key after
+// the grace period of WithUnreceivedReport.
+func (g *Group[K, V]) watchUnreceived(key K, chans []chan<- Result[V]) {
+	if g.options().onUnreceived == nil || len(chans) == 0 {
+		return
+	}
+
+	time.AfterFunc(g.options().unreceivedGrace, func() {
+		n := 0
+		for _, ch := range chans {
+			if len(ch) > 0 {
+				n++
+			}
+		}
+		if n > 0 {
+			g.options().onUnreceived(key, g.keyLabel(key), n)
+		}
+	})
+}
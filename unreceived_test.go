@@ -0,0 +1,43 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnreceivedReport(t *testing.T) {
+	t.Parallel()
+
+	type report struct {
+		key string
+		n   int
+	}
+	reports := make(chan report, 1)
+	g := NewGroup(WithUnreceivedReport[string, int](10*time.Millisecond, func(key string, _ any, n int) {
+		reports <- report{key, n}
+	}))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	received := g.DoChan(context.Background(), "key", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	_ = g.DoChan(context.Background(), "key", func(context.Context) (int, error) { return 2, nil }) // forgotten
+	close(release)
+	<-received
+
+	if r := <-reports; r.key != "key" || r.n != 1 {
+		t.Errorf("report = %+v; want key with 1 unreceived channel", r)
+	}
+
+	<-g.DoChan(context.Background(), "other", func(context.Context) (int, error) { return 1, nil })
+	select {
+	case r := <-reports:
+		t.Errorf("unexpected report %+v", r)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,73 @@
+package singleflight
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// StuckCall describes a call that has been in flight for longer than
+// the maximum duration configured with WithStuckCallDetector.
+type StuckCall[K comparable] struct {
+	Key K
+	// Label is the key rendered by the key redactor of the group, see WithKeyRedactor.
+	Label   any
+	Started time.Time
+	Elapsed time.Duration
+	// Dups is the number of duplicate callers waiting for the call.
+	Dups int
+	// Stack is the stack trace of the leader at the moment the call started.
+	// It is nil unless WithLeaderStacks is used.
+	Stack []byte
+}
+
+// WithStuckCallDetector reports calls which are still in flight after maxDuration.
+// The callback is invoked at most once per call, from its own goroutine.
+// Without it a function that never returns silently blocks its key forever.
+func WithStuckCallDetector[K comparable, V any](maxDuration time.Duration, fn func(StuckCall[K])) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.stuckAfter = maxDuration
+		o.onStuck = fn
+	}
+}
+
+// WithLeaderStacks captures the stack trace of the caller which starts
+// each call. Capturing a stack is relatively expensive, so it is disabled by default.
+func WithLeaderStacks[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.leaderStacks = true
+	}
+}
+
+// startWatchdog arms the stuck call detector for c.
+// Must be called with g.mu held.
+func (g *Group[K, V]) startWatchdog(c *call[V], key K) {
+	if g.options().stuckAfter <= 0 || g.options().onStuck == nil {
+		return
+	}
+	c.watchdog = time.AfterFunc(g.options().stuckAfter, func() {
+		g.mu.Lock()
+		if c.isDone() {
+			g.mu.Unlock()
+			return
+		}
+		info := StuckCall[K]{
+			Key:     key,
+			Label:   g.keyLabel(key),
+			Started: c.started,
+			Elapsed: time.Since(c.started),
+			Dups:    c.dups,
+			Stack:   c.stack,
+		}
+		g.mu.Unlock()
+
+		g.options().onStuck(info)
+	})
+}
+
+// captureStack returns the current stack trace if leader stacks are enabled.
+func (g *Group[K, V]) captureStack() []byte {
+	if !g.options().leaderStacks {
+		return nil
+	}
+	return debug.Stack()
+}
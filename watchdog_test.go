@@ -0,0 +1,72 @@
+package singleflight
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStuckCallDetector(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	stuck := make(chan StuckCall[string], 1)
+	g := NewGroup(
+		WithStuckCallDetector[string, int](10*time.Millisecond, func(info StuckCall[string]) {
+			stuck <- info
+		}),
+		WithLeaderStacks[string, int](),
+	)
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.Do(ctx, "key", func(context.Context) (int, error) {
+			<-release
+			return 1, nil
+		})
+	}()
+
+	select {
+	case info := <-stuck:
+		if info.Key != "key" {
+			t.Errorf("stuck key = %q; want %q", info.Key, "key")
+		}
+		if info.Elapsed < 10*time.Millisecond {
+			t.Errorf("stuck elapsed = %v; want at least 10ms", info.Elapsed)
+		}
+		if !bytes.Contains(info.Stack, []byte("TestStuckCallDetector")) {
+			t.Errorf("leader stack does not contain the test function:\n%s", info.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stuck call was not reported")
+	}
+
+	close(release)
+	<-done
+}
+
+func TestStuckCallDetectorFastCall(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	stuck := make(chan StuckCall[string], 1)
+	g := NewGroup(WithStuckCallDetector[string, int](20*time.Millisecond, func(info StuckCall[string]) {
+		stuck <- info
+	}))
+
+	v, _, err := g.Do(ctx, "key", func(context.Context) (int, error) {
+		return 1, nil
+	})
+	if v != 1 || err != nil {
+		t.Fatalf("Do = %v, %v; want 1, nil", v, err)
+	}
+
+	select {
+	case info := <-stuck:
+		t.Errorf("unexpected stuck call report for %q", info.Key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,50 @@
+package singleflight
+
+// WithHighWatermark calls fn with the number of executions in progress when it reaches n,
+// an early signal that key cardinality or a slow backend makes the in-flight set balloon.
+// fn is called again only after the number went back down to the low watermark,
+// see WithLowWatermark, which defaults to n/2.
+func WithHighWatermark[K comparable, V any](n int, fn func(inFlight int)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.highWatermark = n
+		o.onHigh = fn
+	}
+}
+
+// WithLowWatermark calls fn with the number of executions in progress when it goes
+// back down to n after reaching the high watermark, see WithHighWatermark.
+func WithLowWatermark[K comparable, V any](n int, fn func(inFlight int)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.lowWatermark = n
+		o.onLow = fn
+	}
+}
+
+// crossWatermarks updates the watermark state after the number of executions in progress
+// changed and returns the function notifying the crossing, if any, to call once g.mu is released.
+// Must be called with g.mu held.
+func (g *Group[K, V]) crossWatermarks() func() {
+	high := g.options().highWatermark
+	if high <= 0 {
+		return func() {}
+	}
+	low := g.options().lowWatermark
+	if low <= 0 {
+		low = high / 2
+	}
+
+	n := len(g.running)
+	switch {
+	case !g.aboveHigh && n >= high:
+		g.aboveHigh = true
+		if fn := g.options().onHigh; fn != nil {
+			return func() { fn(n) }
+		}
+	case g.aboveHigh && n <= low:
+		g.aboveHigh = false
+		if fn := g.options().onLow; fn != nil {
+			return func() { fn(n) }
+		}
+	}
+	return func() {}
+}
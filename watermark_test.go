@@ -0,0 +1,60 @@
+package singleflight
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatermarks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(name string) func(int) {
+		return func(n int) {
+			mu.Lock()
+			events = append(events, name+strconv.Itoa(n))
+			mu.Unlock()
+		}
+	}
+	g := NewGroup(
+		WithHighWatermark[string, int](3, record("high")),
+		WithLowWatermark[string, int](1, record("low")),
+	)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	chans := make([]<-chan Result[int], 0, 4)
+	for i := range 4 {
+		chans = append(chans, g.DoChan(context.Background(), strconv.Itoa(i), func(context.Context) (int, error) {
+			started <- struct{}{}
+			<-release
+			return i, nil
+		}))
+	}
+	for range 4 {
+		<-started
+	}
+	close(release)
+	for _, ch := range chans {
+		<-ch
+	}
+
+	// the low watermark is notified by the goroutine of a call after delivering its result
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		if len(events) >= 2 || time.Now().After(deadline) {
+			break
+		}
+		mu.Unlock()
+	}
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "high3" || events[1] != "low1" {
+		t.Errorf("events = %v; want [high3 low1]", events)
+	}
+}
@@ -0,0 +1,98 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// WeakCache caches values computed through singleflight only as long as they are
+// referenced elsewhere: it holds weak pointers, so the garbage collector can reclaim
+// a large value nobody uses anymore, and a value still alive is reused instead of
+// being computed again. There is no TTL, the lifetime of values is decided by the GC.
+// The zero value is ready to use.
+type WeakCache[K comparable, T any] struct {
+	group Group[K, *T]
+
+	mu     sync.Mutex
+	values map[K]weak.Pointer[T] // lazily initialized
+}
+
+// weakEntry identifies a value cached by WeakCache, for its cleanup.
+type weakEntry[K comparable, T any] struct {
+	key K
+	ptr weak.Pointer[T]
+}
+
+// Get returns the value cached for key if it is still alive, computing it with fn otherwise.
+// Concurrent misses for the same key share one execution of fn. A nil value is not cached.
+func (c *WeakCache[K, T]) Get(ctx context.Context, key K, fn doFunc[*T]) (*T, error) {
+	if v, ok := c.Load(key); ok {
+		return v, nil
+	}
+
+	v, _, err := c.group.Do(ctx, key, func(ctx context.Context) (*T, error) {
+		// a previous execution may have completed between Load and Do
+		if v, ok := c.Load(key); ok {
+			return v, nil
+		}
+		v, err := fn(ctx)
+		if err == nil && v != nil {
+			c.store(key, v)
+		}
+		return v, err
+	})
+	return v, err
+}
+
+// Load returns the value cached for key without computing it,
+// or false if there is none or it was reclaimed.
+func (c *WeakCache[K, T]) Load(key K) (*T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v := c.values[key].Value()
+	return v, v != nil
+}
+
+// Forget removes the value cached for key, so the next Get computes it again.
+func (c *WeakCache[K, T]) Forget(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+}
+
+// Len returns the number of cached values, including reclaimed ones whose cleanup
+// has not run yet.
+func (c *WeakCache[K, T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.values)
+}
+
+// store caches v for key and removes it once reclaimed.
+func (c *WeakCache[K, T]) store(key K, v *T) {
+	ptr := weak.Make(v)
+
+	c.mu.Lock()
+	if c.values == nil {
+		c.values = make(map[K]weak.Pointer[T])
+	}
+	c.values[key] = ptr
+	c.mu.Unlock()
+
+	runtime.AddCleanup(v, c.cleanup, weakEntry[K, T]{key: key, ptr: ptr})
+}
+
+// cleanup removes the entry of a reclaimed value, unless it was replaced meanwhile.
+func (c *WeakCache[K, T]) cleanup(ent weakEntry[K, T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values[ent.key] == ent.ptr {
+		delete(c.values, ent.key)
+	}
+}
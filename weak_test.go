@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWeakCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var c WeakCache[string, [1 << 10]byte]
+
+	calls := 0
+	fn := func(context.Context) (*[1 << 10]byte, error) {
+		calls++
+		return new([1 << 10]byte), nil
+	}
+
+	func() {
+		v, err := c.Get(ctx, "key", fn)
+		if v == nil || err != nil {
+			t.Fatalf("Get = %v, %v; want a value, nil", v, err)
+		}
+		if again, _ := c.Get(ctx, "key", fn); again != v || calls != 1 {
+			t.Errorf("Get of a live value must reuse it, calls = %d", calls)
+		}
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); c.Len() > 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("reclaimed value must be removed")
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := c.Load("key"); ok {
+		t.Error("reclaimed value must not be loaded")
+	}
+	_, _ = c.Get(ctx, "key", fn)
+	if calls != 2 {
+		t.Errorf("fn called %d times after reclaim; want 2", calls)
+	}
+}
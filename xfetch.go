@@ -0,0 +1,35 @@
+package singleflight
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// WithEarlyRefresh enables probabilistic early expiration (XFetch) of cached values.
+// Each read of a value close to its expiry refreshes it with a probability growing
+// as the expiry approaches and with the time the value took to compute, so hot keys
+// are refreshed by a single flight shortly before their TTL instead of all callers
+// missing at once. beta scales the eagerness, 1 is the usual choice.
+// Callers which do not trigger the refresh keep getting the cached value.
+func WithEarlyRefresh[K comparable, V any](beta float64) CacheOption[K, V] {
+	return func(c *Cache[K, V]) {
+		c.beta = beta
+	}
+}
+
+// refreshEarly reports whether a read at now of the valid entry ent should refresh it.
+func (c *Cache[K, V]) refreshEarly(ent lruEntry[K, cacheEntry[V]], now time.Time) bool {
+	if c.beta <= 0 || ent.expires.IsZero() || ent.val.delta <= 0 {
+		return false
+	}
+
+	random := rand.Float64 // nolint: gosec
+	if c.rand != nil {
+		random = c.rand
+	}
+
+	// XFetch: refresh if now - delta * beta * ln(rand()) >= expiry
+	gap := -float64(ent.val.delta) * c.beta * math.Log(random())
+	return !now.Add(time.Duration(gap)).Before(ent.expires)
+}
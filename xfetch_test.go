@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEarlyRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewCache(100*time.Millisecond, WithEarlyRefresh[string, int](1))
+
+	calls := 0
+	fn := func(context.Context) (int, error) {
+		calls++
+		time.Sleep(time.Millisecond) // give the computation a measurable cost
+		return calls, nil
+	}
+
+	if v, _ := c.Get(ctx, "key", fn); v != 1 {
+		t.Fatalf("Get = %d; want 1", v)
+	}
+
+	// far from the expiry with an average random value: served from cache
+	c.rand = func() float64 { return 0.5 }
+	if v, _ := c.Get(ctx, "key", fn); v != 1 {
+		t.Errorf("Get = %d; want cached 1", v)
+	}
+
+	// a random value close to 0 stretches the gap beyond the TTL: refreshed
+	c.rand = func() float64 { return 1e-300 }
+	if v, _ := c.Get(ctx, "key", fn); v != 2 {
+		t.Errorf("Get = %d; want refreshed 2", v)
+	}
+
+	// a failed early refresh keeps serving the cached value
+	v, err := c.Get(ctx, "key", func(context.Context) (int, error) {
+		time.Sleep(time.Millisecond)
+		return 0, errors.New("some error")
+	})
+	if v != 2 || err != nil {
+		t.Errorf("Get = %v, %v; want cached 2, nil", v, err)
+	}
+}